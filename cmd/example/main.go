@@ -79,10 +79,9 @@ func runBasicExample() {
 	}
 
 	// 创建处理时间记录器
-	processingTime, err := meter.Float64Histogram(
-		"example.processing_time",
-		metric.WithDescription("Time taken to process requests"),
-		metric.WithUnit("ms"),
+	processingTime, err := telemetry.NewHistogram(meter,
+		"example.processing_time", "ms",
+		"Time taken to process requests",
 	)
 	if err != nil {
 		logger.Error("Failed to create histogram", zap.Error(err))
@@ -165,8 +164,12 @@ func processItem(
 		zap.String("item_name", item.name),
 	)
 
-	// 模拟处理
+	// 模拟处理，计时结果在成功后记录到直方图
 	startTime := time.Now()
+	stopTimer := telemetry.StartTimer(ctx, histogram,
+		attribute.Int("item.id", item.id),
+		attribute.String("item.name", item.name),
+	)
 
 	// 记录事件
 	telemetry.AddSpanEvent(ctx, "item_processing_started",
@@ -179,9 +182,9 @@ func processItem(
 		return err
 	}
 
-	// 计算处理时间
+	// 记录处理时间
+	stopTimer()
 	duration := time.Since(startTime)
-	durationMs := float64(duration.Milliseconds())
 
 	// 记录指标
 	counter.Add(ctx, 1, metric.WithAttributes(
@@ -189,11 +192,6 @@ func processItem(
 		attribute.String("item.name", item.name),
 	))
 
-	histogram.Record(ctx, durationMs, metric.WithAttributes(
-		attribute.Int("item.id", item.id),
-		attribute.String("item.name", item.name),
-	))
-
 	weightHistogram.Record(ctx, int64(item.weight), metric.WithAttributes(
 		attribute.Int("item.id", item.id),
 		attribute.String("item.name", item.name),
@@ -39,6 +39,11 @@ func (a *Analyzer) AnalyzeData(ctx context.Context, id string, data []byte) ([]b
 	)
 	defer span.End()
 
+	// 空数据直接返回，不执行分析步骤
+	if err := checkEmptyData(ctx, data); err != nil {
+		return nil, err
+	}
+
 	// 获取带有 trace 上下文的日志记录器
 	logger := telemetry.LoggerWithContext(ctx)
 	logger.Info("Analyzing data",
@@ -67,11 +72,9 @@ func (a *Analyzer) AnalyzeData(ctx context.Context, id string, data []byte) ([]b
 			attribute.Int("input_size", len(taskData)),
 		)
 
-		// 使用 WithSpan 包装每个分析步骤
-		err = telemetry.WithSpan(ctx, fmt.Sprintf("analyzer.%s", task.name), func(taskCtx context.Context) error {
-			var taskErr error
-			processedData, taskErr = task.fn(taskCtx, taskData)
-			return taskErr
+		// 使用 WithSpanValue 包装每个分析步骤
+		processedData, err = telemetry.WithSpanValue(ctx, fmt.Sprintf("analyzer.%s", task.name), func(taskCtx context.Context) ([]byte, error) {
+			return task.fn(taskCtx, taskData)
 		})
 
 		if err != nil {
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"optl/internal/telemetry"
+)
+
+// ErrEmptyData 输入数据为 nil 或空时返回，调用方可借此区分空输入与处理失败
+var ErrEmptyData = errors.New("empty data")
+
+// checkEmptyData 数据为空时在当前 span 上记录 data_empty 事件并返回 ErrEmptyData
+func checkEmptyData(ctx context.Context, data []byte) error {
+	if len(data) > 0 {
+		return nil
+	}
+	telemetry.AddSpanEvent(ctx, "data_empty")
+	telemetry.LoggerWithContext(ctx).Warn("Empty data received")
+	return ErrEmptyData
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTestTracing 将全局 tracer provider 替换为同步导出到内存的 provider，测试结束时恢复
+func setupTestTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// hasEvent 判断指定名称的 span 是否记录了事件
+func hasEvent(spans tracetest.SpanStubs, spanName, event string) bool {
+	for _, s := range spans {
+		if s.Name != spanName {
+			continue
+		}
+		for _, e := range s.Events {
+			if e.Name == event {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestEmptyDataGuards(t *testing.T) {
+	storage := NewStorage("test-storage")
+	analyzer := NewAnalyzer("test-analyzer")
+	processor := NewProcessor("test-processor", storage, analyzer)
+
+	services := []struct {
+		span string
+		call func(ctx context.Context, data []byte) error
+	}{
+		{"processor.process_data", func(ctx context.Context, data []byte) error {
+			_, err := processor.ProcessData(ctx, "id-1", data)
+			return err
+		}},
+		{"analyzer.analyze_data", func(ctx context.Context, data []byte) error {
+			_, err := analyzer.AnalyzeData(ctx, "id-1", data)
+			return err
+		}},
+		{"storage.store_data", func(ctx context.Context, data []byte) error {
+			return storage.StoreData(ctx, "id-1", data)
+		}},
+	}
+	inputs := map[string][]byte{"nil": nil, "empty slice": {}}
+
+	for _, svc := range services {
+		for name, data := range inputs {
+			t.Run(svc.span+"/"+name, func(t *testing.T) {
+				exporter := setupTestTracing(t)
+				if err := svc.call(context.Background(), data); !errors.Is(err, ErrEmptyData) {
+					t.Errorf("err = %v, want ErrEmptyData", err)
+				}
+				if !hasEvent(exporter.GetSpans(), svc.span, "data_empty") {
+					t.Errorf("%s span has no data_empty event", svc.span)
+				}
+			})
+		}
+	}
+}
@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"optl/internal/telemetry"
@@ -42,6 +45,11 @@ func (p *Processor) ProcessData(ctx context.Context, dataID string, data []byte)
 	)
 	defer span.End()
 
+	// 空数据直接返回，不进入后续处理步骤
+	if err := checkEmptyData(ctx, data); err != nil {
+		return nil, err
+	}
+
 	// 记录处理开始的事件
 	telemetry.AddSpanEvent(ctx, "processing_started",
 		attribute.String("data.id", dataID),
@@ -115,6 +123,48 @@ func (p *Processor) ProcessData(ctx context.Context, dataID string, data []byte)
 	return analysisResult, nil
 }
 
+// ProcessBatch 以有界并发处理一批数据（concurrency <= 0 表示不限制），每条数据在
+// processor.process_batch-i 子 span 中执行 ProcessData；单条数据失败不会中断其余数据，
+// 返回成功数据按 dataID 索引的结果，以及附带 dataID 的聚合错误
+func (p *Processor) ProcessBatch(ctx context.Context, items map[string][]byte, concurrency int) (map[string][]byte, error) {
+	// 按 dataID 排序，使子 span 编号与错误顺序稳定
+	ids := make([]string, 0, len(items))
+	for dataID := range items {
+		ids = append(ids, dataID)
+	}
+	sort.Strings(ids)
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string][]byte, len(items))
+		failed  = make(map[string]error)
+	)
+	// ErrorModeRecordOnly：失败只记录到该条目的子 span，不取消其余条目；错误在这里自行汇总
+	err := telemetry.GoWithLimitAndSpan(ctx, "processor.process_batch", concurrency, ids, func(ctx context.Context, dataID string) error {
+		result, err := p.ProcessData(ctx, dataID, items[dataID])
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed[dataID] = err
+			return err
+		}
+		results[dataID] = result
+		return nil
+	}, telemetry.WithErrorMode(telemetry.ErrorModeRecordOnly))
+	if err != nil {
+		return results, err
+	}
+
+	var errs []error
+	for _, dataID := range ids {
+		if err, ok := failed[dataID]; ok {
+			errs = append(errs, fmt.Errorf("data %s: %w", dataID, err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
 // 验证数据
 func (p *Processor) validateData(ctx context.Context, data []byte) error {
 	return telemetry.WithSpan(ctx, "processor.validate_data", func(ctx context.Context) error {
@@ -123,7 +173,7 @@ func (p *Processor) validateData(ctx context.Context, data []byte) error {
 
 		// 模拟验证逻辑
 		if len(data) == 0 {
-			return fmt.Errorf("empty data")
+			return ErrEmptyData
 		}
 
 		// 添加延迟以模拟处理
@@ -135,15 +185,13 @@ func (p *Processor) validateData(ctx context.Context, data []byte) error {
 
 // 转换数据
 func (p *Processor) transformData(ctx context.Context, data []byte) ([]byte, error) {
-	var result []byte
-
-	// 使用 WithSpan 包装函数
-	err := telemetry.WithSpan(ctx, "processor.transform_data", func(ctx context.Context) error {
+	// 使用 WithSpanValue 包装函数
+	return telemetry.WithSpanValue(ctx, "processor.transform_data", func(ctx context.Context) ([]byte, error) {
 		logger := telemetry.LoggerWithContext(ctx)
 		logger.Debug("Transforming data")
 
 		// 模拟转换逻辑
-		result = make([]byte, len(data))
+		result := make([]byte, len(data))
 		copy(result, data)
 
 		// 添加延迟以模拟处理
@@ -154,8 +202,6 @@ func (p *Processor) transformData(ctx context.Context, data []byte) ([]byte, err
 			result[i], result[j] = result[j], result[i]
 		}
 
-		return nil
+		return result, nil
 	})
-
-	return result, err
 }
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// findSpan 返回指定名称的 span
+func findSpan(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, s := range spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func TestProcessBatchMixedResults(t *testing.T) {
+	exporter := setupTestTracing(t)
+	processor := NewProcessor("test-processor", NewStorage("test-storage"), NewAnalyzer("test-analyzer"))
+
+	items := map[string][]byte{
+		"a": []byte("first"),
+		"b": nil, // 空数据必然失败
+		"c": []byte("third"),
+	}
+	results, err := processor.ProcessBatch(context.Background(), items, 2)
+
+	if !errors.Is(err, ErrEmptyData) || !strings.Contains(err.Error(), "data b:") {
+		t.Fatalf("err = %v, want ErrEmptyData for data b", err)
+	}
+	if _, ok := results["b"]; ok {
+		t.Error("results contain the failed item b")
+	}
+	// 分析器会以小概率随机失败，因此每条成功数据要么出现在结果中，要么出现在聚合错误中，而不会因 b 失败被取消
+	for _, id := range []string{"a", "c"} {
+		_, ok := results[id]
+		failed := strings.Contains(err.Error(), fmt.Sprintf("data %s:", id))
+		if ok == failed {
+			t.Errorf("item %s: in results = %v, in error = %v, want exactly one", id, ok, failed)
+		}
+	}
+
+	spans := exporter.GetSpans()
+	batch, ok := findSpan(spans, "processor.process_batch")
+	if !ok {
+		t.Fatal("processor.process_batch span not found")
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		child, ok := findSpan(spans, fmt.Sprintf("processor.process_batch-%d", i))
+		if !ok {
+			t.Fatalf("child span for item %s not found", id)
+		}
+		if child.Parent.SpanID() != batch.SpanContext.SpanID() {
+			t.Errorf("item %s span is not a child of the batch span", id)
+		}
+		if id == "b" && child.Status.Code != codes.Error {
+			t.Errorf("item b span status = %v, want Error", child.Status.Code)
+		}
+
+		// 每条数据的 ProcessData span 挂在各自的条目 span 下
+		var nested bool
+		for _, s := range spans {
+			if s.Name == "processor.process_data" && s.Parent.SpanID() == child.SpanContext.SpanID() {
+				nested = true
+			}
+		}
+		if !nested {
+			t.Errorf("item %s span has no processor.process_data child", id)
+		}
+	}
+	var errorCount int64
+	for _, attr := range batch.Attributes {
+		if attr.Key == "batch.error_count" {
+			errorCount = attr.Value.AsInt64()
+		}
+	}
+	if errorCount < 1 {
+		t.Errorf("batch.error_count = %d, want at least 1", errorCount)
+	}
+}
@@ -9,10 +9,21 @@ import (
 	"optl/internal/telemetry"
 
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// StorageOperation 存储操作类型，作为 storage.operation 属性
+type StorageOperation string
+
+const (
+	StorageOpStore StorageOperation = "store"
+	StorageOpGet   StorageOperation = "get"
+)
+
+func init() {
+	telemetry.RegisterEnumValues("storage.operation", StorageOpStore, StorageOpGet)
+}
+
 // Storage 用于存储数据的服务
 type Storage struct {
 	name   string
@@ -30,18 +41,28 @@ func NewStorage(name string) *Storage {
 	}
 }
 
+// spanOpts 返回存储操作 span 共用的选项
+func (s *Storage) spanOpts(op StorageOperation, id string) telemetry.SpanOptions {
+	return telemetry.SpanOpts().Attrs(
+		attribute.String("storage.name", s.name),
+		telemetry.EnumAttr("storage.operation", op),
+		attribute.String("data.id", id),
+	)
+}
+
 // StoreData 存储数据并跟踪
 func (s *Storage) StoreData(ctx context.Context, id string, data []byte) error {
 	// 创建一个存储数据的 span
 	ctx, span := telemetry.ContextWithSpan(ctx, "storage.store_data",
-		trace.WithAttributes(
-			attribute.String("storage.name", s.name),
-			attribute.String("data.id", id),
-			attribute.Int("data.size", len(data)),
-		),
+		s.spanOpts(StorageOpStore, id).Attr(attribute.Int("data.size", len(data)))...,
 	)
 	defer span.End()
 
+	// 空数据直接返回，不写入存储
+	if err := checkEmptyData(ctx, data); err != nil {
+		return err
+	}
+
 	// 获取带有 trace 上下文的日志记录器
 	logger := telemetry.LoggerWithContext(ctx)
 	logger.Info("Storing data",
@@ -88,12 +109,7 @@ func (s *Storage) StoreData(ctx context.Context, id string, data []byte) error {
 // GetData 获取数据并跟踪
 func (s *Storage) GetData(ctx context.Context, id string) ([]byte, error) {
 	// 创建一个获取数据的 span
-	ctx, span := telemetry.ContextWithSpan(ctx, "storage.get_data",
-		trace.WithAttributes(
-			attribute.String("storage.name", s.name),
-			attribute.String("data.id", id),
-		),
-	)
+	ctx, span := telemetry.ContextWithSpan(ctx, "storage.get_data", s.spanOpts(StorageOpGet, id)...)
 	defer span.End()
 
 	// 获取带有 trace 上下文的日志记录器
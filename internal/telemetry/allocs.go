@@ -0,0 +1,41 @@
+package telemetry
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanAllocsEnabled 是否在 WithSpanAllocs 中统计内存分配
+var spanAllocsEnabled atomic.Bool
+
+// SetSpanAllocsEnabled 开启或关闭 WithSpanAllocs 的内存分配统计
+func SetSpanAllocsEnabled(enabled bool) {
+	spanAllocsEnabled.Store(enabled)
+}
+
+// WithSpanAllocs 与 WithSpan 相同，并在开启统计时将 fn 执行期间的内存分配记录为
+// span.allocated_bytes 与 span.alloc_count 属性
+// runtime.ReadMemStats 会短暂 stop-the-world，且统计的是整个进程的分配（包括并发 goroutine），
+// 仅适用于性能分析构建，需通过 Config.EnableSpanAllocs 显式开启；关闭时不读取内存统计
+func WithSpanAllocs(ctx context.Context, name string, fn func(context.Context) error, opts ...trace.SpanStartOption) error {
+	if !spanAllocsEnabled.Load() {
+		return WithSpan(ctx, name, fn, opts...)
+	}
+
+	return WithSpan(ctx, name, func(ctx context.Context) error {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err := fn(ctx)
+		runtime.ReadMemStats(&after)
+
+		SetSpanAttributes(ctx,
+			attribute.Int64("span.allocated_bytes", int64(after.TotalAlloc-before.TotalAlloc)),
+			attribute.Int64("span.alloc_count", int64(after.Mallocs-before.Mallocs)),
+		)
+		return err
+	}, opts...)
+}
@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+// allocSink 防止测试中的分配被编译器优化掉
+var allocSink []byte
+
+func TestWithSpanAllocs(t *testing.T) {
+	exporter := setupTestTracing(t)
+	t.Cleanup(func() { SetSpanAllocsEnabled(false) })
+	allocate := func(context.Context) error {
+		allocSink = make([]byte, 1<<20)
+		return nil
+	}
+
+	SetSpanAllocsEnabled(false)
+	if err := WithSpanAllocs(context.Background(), "disabled", allocate); err != nil {
+		t.Fatal(err)
+	}
+	SetSpanAllocsEnabled(true)
+	if err := WithSpanAllocs(context.Background(), "enabled", allocate); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	disabled, ok := findSpan(spans, "disabled")
+	if !ok {
+		t.Fatal("disabled span not exported")
+	}
+	for _, key := range []string{"span.allocated_bytes", "span.alloc_count"} {
+		if v, ok := attrValue(disabled.Attributes, key); ok {
+			t.Errorf("disabled span has %s = %s", key, v)
+		}
+	}
+
+	enabled, ok := findSpan(spans, "enabled")
+	if !ok {
+		t.Fatal("enabled span not exported")
+	}
+	// 统计的是整个进程的分配，只能断言下限
+	bytesAttr, _ := attrValue(enabled.Attributes, "span.allocated_bytes")
+	if n, err := strconv.ParseInt(bytesAttr, 10, 64); err != nil || n < 1<<20 {
+		t.Errorf("span.allocated_bytes = %q, want at least %d", bytesAttr, 1<<20)
+	}
+	countAttr, _ := attrValue(enabled.Attributes, "span.alloc_count")
+	if n, err := strconv.ParseInt(countAttr, 10, 64); err != nil || n < 1 {
+		t.Errorf("span.alloc_count = %q, want at least 1", countAttr)
+	}
+}
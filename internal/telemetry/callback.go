@@ -0,0 +1,181 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+	"go.uber.org/zap"
+)
+
+// observableFactory 在给定 meter 上以相同名称与选项重新创建观测型 instrument
+type observableFactory func(metric.Meter) (metric.Observable, error)
+
+// metricCallback 通过 RegisterMetricCallback 注册的回调
+// instruments 为调用方创建时得到的 instrument，重建 provider 后回调中观测它们的调用会被映射到新 provider 上的对应 instrument
+type metricCallback struct {
+	scope       string
+	instruments []metric.Observable
+	cb          metric.Callback
+	reg         metric.Registration
+}
+
+// register 在 meter 上重新创建 instruments 并注册回调，factories 记录了每个 instrument 的创建方式
+func (c *metricCallback) register(meter metric.Meter, factories map[metric.Observable]observableFactory) error {
+	mapping := make(map[metric.Observable]metric.Observable, len(c.instruments))
+	current := make([]metric.Observable, 0, len(c.instruments))
+	for _, inst := range c.instruments {
+		factory, ok := factories[inst]
+		if !ok {
+			return fmt.Errorf("instrument was not created by Provider.Meter")
+		}
+		recreated, err := factory(meter)
+		if err != nil {
+			return err
+		}
+		mapping[inst] = recreated
+		current = append(current, recreated)
+	}
+
+	reg, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		return c.cb(ctx, remapObserver{Observer: o, instruments: mapping})
+	}, current...)
+	if err != nil {
+		return err
+	}
+	c.reg = reg
+	return nil
+}
+
+// callbackRegistration RegisterMetricCallback 返回的注册句柄，注销时同时停止在重建后的 provider 上重新注册
+type callbackRegistration struct {
+	embedded.Registration
+	p *Provider
+	c *metricCallback
+}
+
+// Unregister 注销回调
+func (r *callbackRegistration) Unregister() error {
+	r.p.callbackMu.Lock()
+	defer r.p.callbackMu.Unlock()
+	for i, c := range r.p.callbacks {
+		if c == r.c {
+			r.p.callbacks = append(r.p.callbacks[:i], r.p.callbacks[i+1:]...)
+			break
+		}
+	}
+	if r.c.reg == nil {
+		return nil
+	}
+	err := r.c.reg.Unregister()
+	r.c.reg = nil
+	return err
+}
+
+// reregisterMetricCallbacks 在新的 meter provider 上重新注册全部回调，调用方需持有 configMu
+// 旧 provider 即将关闭，其上的注册直接注销；无法重新注册的回调输出警告后跳过
+func (p *Provider) reregisterMetricCallbacks(mp metric.MeterProvider) {
+	p.callbackMu.Lock()
+	defer p.callbackMu.Unlock()
+	for _, c := range p.callbacks {
+		if c.reg != nil {
+			_ = c.reg.Unregister()
+			c.reg = nil
+		}
+		if err := c.register(scopedMeter(mp, c.scope), p.observables); err != nil {
+			Logger().Warn("Failed to re-register metric callback on rebuilt provider",
+				zap.String("meter", c.scope),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// remapObserver 将对调用方持有的 instrument 的观测转发到重建后的对应 instrument
+type remapObserver struct {
+	metric.Observer
+	instruments map[metric.Observable]metric.Observable
+}
+
+func (o remapObserver) ObserveInt64(inst metric.Int64Observable, value int64, opts ...metric.ObserveOption) {
+	if mapped, ok := o.instruments[inst].(metric.Int64Observable); ok {
+		inst = mapped
+	}
+	o.Observer.ObserveInt64(inst, value, opts...)
+}
+
+func (o remapObserver) ObserveFloat64(inst metric.Float64Observable, value float64, opts ...metric.ObserveOption) {
+	if mapped, ok := o.instruments[inst].(metric.Float64Observable); ok {
+		inst = mapped
+	}
+	o.Observer.ObserveFloat64(inst, value, opts...)
+}
+
+// providerMeter Provider.Meter 返回的 meter，记录观测型 instrument 的创建方式，
+// 使 RegisterMetricCallback 注册的回调能在 UpdateResourceAttribute 重建 provider 后重新注册
+type providerMeter struct {
+	metric.Meter
+	p *Provider
+}
+
+// recordObservable 记录 instrument 的创建方式，创建失败时不记录
+func (m *providerMeter) recordObservable(inst metric.Observable, err error, factory observableFactory) {
+	if err != nil {
+		return
+	}
+	m.p.callbackMu.Lock()
+	defer m.p.callbackMu.Unlock()
+	if m.p.observables == nil {
+		m.p.observables = make(map[metric.Observable]observableFactory)
+	}
+	m.p.observables[inst] = factory
+}
+
+func (m *providerMeter) Int64ObservableCounter(name string, opts ...metric.Int64ObservableCounterOption) (metric.Int64ObservableCounter, error) {
+	inst, err := m.Meter.Int64ObservableCounter(name, opts...)
+	m.recordObservable(inst, err, func(meter metric.Meter) (metric.Observable, error) {
+		return meter.Int64ObservableCounter(name, opts...)
+	})
+	return inst, err
+}
+
+func (m *providerMeter) Int64ObservableUpDownCounter(name string, opts ...metric.Int64ObservableUpDownCounterOption) (metric.Int64ObservableUpDownCounter, error) {
+	inst, err := m.Meter.Int64ObservableUpDownCounter(name, opts...)
+	m.recordObservable(inst, err, func(meter metric.Meter) (metric.Observable, error) {
+		return meter.Int64ObservableUpDownCounter(name, opts...)
+	})
+	return inst, err
+}
+
+func (m *providerMeter) Int64ObservableGauge(name string, opts ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	inst, err := m.Meter.Int64ObservableGauge(name, opts...)
+	m.recordObservable(inst, err, func(meter metric.Meter) (metric.Observable, error) {
+		return meter.Int64ObservableGauge(name, opts...)
+	})
+	return inst, err
+}
+
+func (m *providerMeter) Float64ObservableCounter(name string, opts ...metric.Float64ObservableCounterOption) (metric.Float64ObservableCounter, error) {
+	inst, err := m.Meter.Float64ObservableCounter(name, opts...)
+	m.recordObservable(inst, err, func(meter metric.Meter) (metric.Observable, error) {
+		return meter.Float64ObservableCounter(name, opts...)
+	})
+	return inst, err
+}
+
+func (m *providerMeter) Float64ObservableUpDownCounter(name string, opts ...metric.Float64ObservableUpDownCounterOption) (metric.Float64ObservableUpDownCounter, error) {
+	inst, err := m.Meter.Float64ObservableUpDownCounter(name, opts...)
+	m.recordObservable(inst, err, func(meter metric.Meter) (metric.Observable, error) {
+		return meter.Float64ObservableUpDownCounter(name, opts...)
+	})
+	return inst, err
+}
+
+func (m *providerMeter) Float64ObservableGauge(name string, opts ...metric.Float64ObservableGaugeOption) (metric.Float64ObservableGauge, error) {
+	inst, err := m.Meter.Float64ObservableGauge(name, opts...)
+	m.recordObservable(inst, err, func(meter metric.Meter) (metric.Observable, error) {
+		return meter.Float64ObservableGauge(name, opts...)
+	})
+	return inst, err
+}
@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedEnvelope 在 channel 中传递的值及发送方的追踪上下文
+type tracedEnvelope[T any] struct {
+	spanContext trace.SpanContext
+	baggage     baggage.Baggage
+	value       T
+}
+
+// TracedSender 带追踪上下文的 channel 发送端
+type TracedSender[T any] struct {
+	ch chan tracedEnvelope[T]
+}
+
+// TracedReceiver 带追踪上下文的 channel 接收端
+type TracedReceiver[T any] struct {
+	ch chan tracedEnvelope[T]
+}
+
+// TracedChannel 创建一对带追踪上下文的 channel 发送端/接收端，用于进程内生产者/消费者之间的交接
+// 发送时捕获当前 span 上下文与 baggage，接收时在消费者一侧重建上下文，使消费者创建的 span 与生产者关联到同一 trace
+func TracedChannel[T any](bufferSize int) (*TracedSender[T], *TracedReceiver[T]) {
+	ch := make(chan tracedEnvelope[T], bufferSize)
+	return &TracedSender[T]{ch: ch}, &TracedReceiver[T]{ch: ch}
+}
+
+// Send 发送值并附带 ctx 中的追踪上下文，channel 已满时阻塞直到发送成功或 ctx 结束
+func (s *TracedSender[T]) Send(ctx context.Context, value T) error {
+	env := tracedEnvelope[T]{
+		spanContext: trace.SpanContextFromContext(ctx),
+		baggage:     baggage.FromContext(ctx),
+		value:       value,
+	}
+	select {
+	case s.ch <- env:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 关闭 channel，之后 Receive 在取完剩余值后返回 false
+func (s *TracedSender[T]) Close() {
+	close(s.ch)
+}
+
+// Receive 接收值，返回的上下文基于 context.Background()，携带发送方的 span 上下文（作为远端父 span）与 baggage
+// channel 关闭且为空时返回 false
+func (r *TracedReceiver[T]) Receive() (context.Context, T, bool) {
+	env, ok := <-r.ch
+	if !ok {
+		var zero T
+		return context.Background(), zero, false
+	}
+
+	ctx := baggage.ContextWithBaggage(context.Background(), env.baggage)
+	ctx = ContextWithRemoteSpanContext(ctx, env.spanContext)
+	return ctx, env.value, true
+}
+
+// Len 返回 channel 中尚未接收的值数量
+func (r *TracedReceiver[T]) Len() int {
+	return len(r.ch)
+}
@@ -0,0 +1,229 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.uber.org/zap"
+)
+
+// cloudDetectTimeout 查询云元数据服务的超时，不在云环境中时元数据地址通常不可达，需尽快放弃
+const cloudDetectTimeout = time.Second
+
+// 云元数据服务地址，声明为变量以便指向模拟服务
+var (
+	gcpMetadataURL   = "http://metadata.google.internal/computeMetadata/v1"
+	awsMetadataURL   = "http://169.254.169.254/latest"
+	azureMetadataURL = "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01"
+)
+
+// cloudDetectors 按 auto 模式下的优先顺序排列的云元数据检测器
+var cloudDetectors = []struct {
+	name   string
+	detect func(ctx context.Context, client *http.Client) ([]attribute.KeyValue, error)
+}{
+	{"aws", detectAWS},
+	{"gcp", detectGCP},
+	{"azure", detectAzure},
+}
+
+// 按 CloudDetector 缓存的检测结果，云元数据在进程生命周期内不变，只需查询一次
+var (
+	cloudAttrsMu    sync.Mutex
+	cloudAttrsCache = map[string][]attribute.KeyValue{}
+)
+
+// cloudAttributes 返回 detector 对应的云资源属性，首次调用时检测并缓存，
+// 后续的 SetupMetrics、setupTracing 与 UpdateResourceAttribute 直接复用，不再查询元数据服务
+func cloudAttributes(detector string) []attribute.KeyValue {
+	if detector == "" {
+		return nil
+	}
+
+	cloudAttrsMu.Lock()
+	defer cloudAttrsMu.Unlock()
+	if attrs, ok := cloudAttrsCache[detector]; ok {
+		return attrs
+	}
+	attrs := detectCloudAttributes(detector)
+	cloudAttrsCache[detector] = attrs
+	return attrs
+}
+
+// detectCloudAttributes 按 CloudDetector 查询云元数据服务，返回 cloud.* 与 host.id 资源属性
+// auto 模式并发查询全部云平台并按 aws、gcp、azure 的顺序取第一个成功的结果；
+// 检测失败（如不在该云环境中）时输出日志并返回 nil，不影响初始化
+func detectCloudAttributes(detector string) []attribute.KeyValue {
+	if detector == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cloudDetectTimeout)
+	defer cancel()
+	client := &http.Client{}
+
+	if detector != "auto" {
+		for _, d := range cloudDetectors {
+			if d.name != detector {
+				continue
+			}
+			attrs, err := d.detect(ctx, client)
+			if err != nil {
+				zap.L().Info("Cloud metadata detection failed, continuing without cloud attributes",
+					zap.String("detector", detector),
+					zap.Error(err),
+				)
+				return nil
+			}
+			return attrs
+		}
+		zap.L().Warn("Unknown cloud detector, ignoring", zap.String("detector", detector))
+		return nil
+	}
+
+	type result struct {
+		attrs []attribute.KeyValue
+		err   error
+	}
+	results := make([]chan result, len(cloudDetectors))
+	for i, d := range cloudDetectors {
+		results[i] = make(chan result, 1)
+		go func(ch chan<- result, detect func(context.Context, *http.Client) ([]attribute.KeyValue, error)) {
+			attrs, err := detect(ctx, client)
+			ch <- result{attrs: attrs, err: err}
+		}(results[i], d.detect)
+	}
+	for _, ch := range results {
+		if r := <-ch; r.err == nil {
+			return r.attrs
+		}
+	}
+	zap.L().Info("No cloud metadata service detected, continuing without cloud attributes")
+	return nil
+}
+
+// detectGCP 从 GCE 元数据服务读取项目、可用区与实例 ID
+func detectGCP(ctx context.Context, client *http.Client) ([]attribute.KeyValue, error) {
+	header := http.Header{"Metadata-Flavor": []string{"Google"}}
+	get := func(path string) (string, error) {
+		body, err := fetchMetadata(ctx, client, http.MethodGet, gcpMetadataURL+path, header)
+		return string(body), err
+	}
+
+	// 可用区形如 projects/123456/zones/us-central1-a
+	zone, err := get("/instance/zone")
+	if err != nil {
+		return nil, err
+	}
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderGCP,
+		semconv.CloudPlatformGCPComputeEngine,
+		semconv.CloudAvailabilityZone(zone),
+	}
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		attrs = append(attrs, semconv.CloudRegion(zone[:i]))
+	}
+	if id, err := get("/instance/id"); err == nil {
+		attrs = append(attrs, semconv.HostID(id))
+	}
+	if project, err := get("/project/project-id"); err == nil {
+		attrs = append(attrs, semconv.CloudAccountID(project))
+	}
+	return attrs, nil
+}
+
+// detectAWS 通过 IMDSv2 读取 EC2 实例身份文档
+func detectAWS(ctx context.Context, client *http.Client) ([]attribute.KeyValue, error) {
+	token, err := fetchMetadata(ctx, client, http.MethodPut, awsMetadataURL+"/api/token",
+		http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": []string{"60"}})
+	if err != nil {
+		return nil, err
+	}
+	body, err := fetchMetadata(ctx, client, http.MethodGet, awsMetadataURL+"/dynamic/instance-identity/document",
+		http.Header{"X-Aws-Ec2-Metadata-Token": []string{string(token)}})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		AccountID        string `json:"accountId"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse AWS identity document: %w", err)
+	}
+	return []attribute.KeyValue{
+		semconv.CloudProviderAWS,
+		semconv.CloudPlatformAWSEC2,
+		semconv.CloudRegion(doc.Region),
+		semconv.CloudAvailabilityZone(doc.AvailabilityZone),
+		semconv.HostID(doc.InstanceID),
+		semconv.CloudAccountID(doc.AccountID),
+	}, nil
+}
+
+// detectAzure 从 Azure 实例元数据服务读取虚拟机信息
+func detectAzure(ctx context.Context, client *http.Client) ([]attribute.KeyValue, error) {
+	body, err := fetchMetadata(ctx, client, http.MethodGet, azureMetadataURL,
+		http.Header{"Metadata": []string{"true"}})
+	if err != nil {
+		return nil, err
+	}
+
+	var compute struct {
+		Location       string `json:"location"`
+		Zone           string `json:"zone"`
+		VMID           string `json:"vmId"`
+		SubscriptionID string `json:"subscriptionId"`
+	}
+	if err := json.Unmarshal(body, &compute); err != nil {
+		return nil, fmt.Errorf("failed to parse Azure instance metadata: %w", err)
+	}
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderAzure,
+		semconv.CloudPlatformAzureVM,
+		semconv.CloudRegion(compute.Location),
+		semconv.HostID(compute.VMID),
+		semconv.CloudAccountID(compute.SubscriptionID),
+	}
+	// 未部署在可用区中的虚拟机 zone 为空
+	if compute.Zone != "" {
+		attrs = append(attrs, semconv.CloudAvailabilityZone(compute.Zone))
+	}
+	return attrs, nil
+}
+
+// fetchMetadata 请求元数据服务，非 200 响应视为不在该云环境中
+func fetchMetadata(ctx context.Context, client *http.Client, method, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metadata request to %s returned %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimSpace(string(body))), nil
+}
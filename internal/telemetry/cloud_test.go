@@ -0,0 +1,180 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+// fakeMetadataServer 统计模拟元数据服务收到的请求数
+type fakeMetadataServer struct {
+	mu       sync.Mutex
+	requests int
+}
+
+func (s *fakeMetadataServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// startFakeMetadataServer 启动只模拟 enabled 云平台的元数据服务（其余平台返回 404），将元数据地址指向它，测试结束时恢复
+func startFakeMetadataServer(t *testing.T, enabled string) *fakeMetadataServer {
+	t.Helper()
+	fake := &fakeMetadataServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/computeMetadata/v1/", func(w http.ResponseWriter, r *http.Request) {
+		if enabled != "gcp" || r.Header.Get("Metadata-Flavor") != "Google" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/zone":
+			w.Write([]byte("projects/123456/zones/us-central1-a"))
+		case "/computeMetadata/v1/instance/id":
+			w.Write([]byte("gce-instance-1"))
+		case "/computeMetadata/v1/project/project-id":
+			w.Write([]byte("my-project"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	mux.HandleFunc("PUT /latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if enabled != "aws" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("token-1"))
+	})
+	mux.HandleFunc("GET /latest/dynamic/instance-identity/document", func(w http.ResponseWriter, r *http.Request) {
+		if enabled != "aws" || r.Header.Get("X-Aws-Ec2-Metadata-Token") != "token-1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"region":"us-east-1","availabilityZone":"us-east-1a","instanceId":"i-0abc","accountId":"111122223333"}`))
+	})
+	mux.HandleFunc("/metadata/instance/compute", func(w http.ResponseWriter, r *http.Request) {
+		if enabled != "azure" || r.Header.Get("Metadata") != "true" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"location":"westeurope","zone":"2","vmId":"vm-1","subscriptionId":"sub-1"}`))
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		fake.requests++
+		fake.mu.Unlock()
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	prevGCP, prevAWS, prevAzure := gcpMetadataURL, awsMetadataURL, azureMetadataURL
+	gcpMetadataURL = server.URL + "/computeMetadata/v1"
+	awsMetadataURL = server.URL + "/latest"
+	azureMetadataURL = server.URL + "/metadata/instance/compute"
+	resetCloudAttributes()
+	t.Cleanup(func() {
+		gcpMetadataURL, awsMetadataURL, azureMetadataURL = prevGCP, prevAWS, prevAzure
+		resetCloudAttributes()
+	})
+	return fake
+}
+
+// resetCloudAttributes 清空检测结果缓存
+func resetCloudAttributes() {
+	cloudAttrsMu.Lock()
+	defer cloudAttrsMu.Unlock()
+	cloudAttrsCache = map[string][]attribute.KeyValue{}
+}
+
+func TestDetectCloudAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  string
+		detector string
+		want     map[attribute.Key]string
+	}{
+		{
+			name: "gcp", enabled: "gcp", detector: "gcp",
+			want: map[attribute.Key]string{
+				semconv.CloudProviderKey:         "gcp",
+				semconv.CloudRegionKey:           "us-central1",
+				semconv.CloudAvailabilityZoneKey: "us-central1-a",
+				semconv.HostIDKey:                "gce-instance-1",
+				semconv.CloudAccountIDKey:        "my-project",
+			},
+		},
+		{
+			name: "aws", enabled: "aws", detector: "aws",
+			want: map[attribute.Key]string{
+				semconv.CloudProviderKey:         "aws",
+				semconv.CloudRegionKey:           "us-east-1",
+				semconv.CloudAvailabilityZoneKey: "us-east-1a",
+				semconv.HostIDKey:                "i-0abc",
+				semconv.CloudAccountIDKey:        "111122223333",
+			},
+		},
+		{
+			name: "azure", enabled: "azure", detector: "azure",
+			want: map[attribute.Key]string{
+				semconv.CloudProviderKey:         "azure",
+				semconv.CloudRegionKey:           "westeurope",
+				semconv.CloudAvailabilityZoneKey: "2",
+				semconv.HostIDKey:                "vm-1",
+				semconv.CloudAccountIDKey:        "sub-1",
+			},
+		},
+		{
+			name: "auto picks the available cloud", enabled: "azure", detector: "auto",
+			want: map[attribute.Key]string{semconv.CloudProviderKey: "azure", semconv.HostIDKey: "vm-1"},
+		},
+		{name: "not in that cloud", enabled: "aws", detector: "gcp"},
+		{name: "auto outside any cloud", enabled: "", detector: "auto"},
+		{name: "unknown detector", enabled: "aws", detector: "openstack"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startFakeMetadataServer(t, tt.enabled)
+			attrs := detectCloudAttributes(tt.detector)
+			if tt.want == nil {
+				if len(attrs) != 0 {
+					t.Errorf("detectCloudAttributes(%q) = %v, want none", tt.detector, attrs)
+				}
+				return
+			}
+			got := map[attribute.Key]string{}
+			for _, kv := range attrs {
+				got[kv.Key] = kv.Value.Emit()
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestCloudAttributesDetectedOnce(t *testing.T) {
+	fake := startFakeMetadataServer(t, "aws")
+
+	cfg := testProviderConfig()
+	cfg.CloudDetector = "aws"
+	for i := 0; i < 3; i++ {
+		res, err := createResource(cfg)
+		if err != nil {
+			t.Fatalf("createResource: %v", err)
+		}
+		if v, ok := res.Set().Value(semconv.HostIDKey); !ok || v.AsString() != "i-0abc" {
+			t.Fatalf("host.id = %v, want i-0abc", v)
+		}
+	}
+	// 令牌与身份文档各请求一次，之后的调用复用缓存
+	if got := fake.count(); got != 2 {
+		t.Errorf("metadata server received %d requests, want 2", got)
+	}
+}
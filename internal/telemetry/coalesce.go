@@ -0,0 +1,103 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CoalescingOption 配置合并计数器的选项
+type CoalescingOption func(*CoalescingCounter)
+
+// WithCoalescingInterval 设置定时刷新的间隔（默认 1s）
+func WithCoalescingInterval(interval time.Duration) CoalescingOption {
+	return func(c *CoalescingCounter) {
+		c.interval = interval
+	}
+}
+
+// WithCoalescingThreshold 设置累计增量达到该值时立即刷新（默认 0，仅定时刷新）
+func WithCoalescingThreshold(threshold int64) CoalescingOption {
+	return func(c *CoalescingCounter) {
+		c.threshold = threshold
+	}
+}
+
+// CoalescingCounter 在本地原子变量中累计增量，定时或达到阈值时一次性写入底层计数器
+// 适用于极高频事件：热路径上只有一次原子加法，避免每次记录都对属性集做哈希；
+// 属性在创建时固定，指标值最多滞后一个刷新间隔
+type CoalescingCounter struct {
+	counter   metric.Int64Counter
+	attrs     metric.AddOption
+	interval  time.Duration
+	threshold int64
+
+	pending   atomic.Int64
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewCoalescingCounter 创建合并计数器并启动后台刷新，使用完毕后需调用 Close
+func NewCoalescingCounter(counter metric.Int64Counter, attrs []attribute.KeyValue, opts ...CoalescingOption) *CoalescingCounter {
+	c := &CoalescingCounter{
+		counter:  counter,
+		attrs:    metric.WithAttributeSet(attribute.NewSet(attrs...)),
+		interval: time.Second,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.interval <= 0 {
+		c.interval = time.Second
+	}
+
+	go c.run()
+	return c
+}
+
+// Add 累计增量，达到阈值时立即刷新
+func (c *CoalescingCounter) Add(n int64) {
+	if c.pending.Add(n) >= c.threshold && c.threshold > 0 {
+		c.flush()
+	}
+}
+
+// Close 停止后台刷新并写入剩余的增量
+func (c *CoalescingCounter) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+		c.flush()
+	})
+}
+
+// run 定时刷新累计的增量
+func (c *CoalescingCounter) run() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// flush 将累计的增量写入底层计数器
+func (c *CoalescingCounter) flush() {
+	if n := c.pending.Swap(0); n != 0 {
+		c.counter.Add(context.Background(), n, c.attrs)
+	}
+}
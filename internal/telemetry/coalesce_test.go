@@ -0,0 +1,141 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// newTestCounter 创建挂载手动 reader 的 MeterProvider，测试结束时关闭
+func newTestCounter(t testing.TB) (*sdkmetric.ManualReader, *sdkmetric.MeterProvider) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() { _ = mp.Shutdown(context.Background()) })
+	return reader, mp
+}
+
+// counterValue 返回计数器在 attrs 下的当前累计值
+func counterValue(t *testing.T, reader sdkmetric.Reader, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+	_, m, ok := collectMetric(t, reader, name)
+	if !ok {
+		return 0
+	}
+	want := attribute.NewSet(attrs...)
+	for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+		if dp.Attributes.Equals(&want) {
+			return dp.Value
+		}
+	}
+	return 0
+}
+
+func TestCoalescingCounterThreshold(t *testing.T) {
+	reader, mp := newTestCounter(t)
+	counter, _ := mp.Meter("test").Int64Counter("events")
+	attrs := []attribute.KeyValue{attribute.String("kind", "tick")}
+	// 间隔足够长，只有达到阈值才会刷新
+	c := NewCoalescingCounter(counter, attrs, WithCoalescingInterval(time.Hour), WithCoalescingThreshold(10))
+	defer c.Close()
+
+	for range 9 {
+		c.Add(1)
+	}
+	if got := counterValue(t, reader, "events", attrs...); got != 0 {
+		t.Errorf("value below threshold = %d, want 0", got)
+	}
+	c.Add(1)
+	if got := counterValue(t, reader, "events", attrs...); got != 10 {
+		t.Errorf("value at threshold = %d, want 10", got)
+	}
+}
+
+func TestCoalescingCounterInterval(t *testing.T) {
+	reader, mp := newTestCounter(t)
+	counter, _ := mp.Meter("test").Int64Counter("events")
+	c := NewCoalescingCounter(counter, nil, WithCoalescingInterval(10*time.Millisecond))
+	defer c.Close()
+
+	c.Add(3)
+	waitFor(t, time.Second, func() bool {
+		return counterValue(t, reader, "events") == 3
+	})
+}
+
+func TestCoalescingCounterCloseFlushes(t *testing.T) {
+	reader, mp := newTestCounter(t)
+	counter, _ := mp.Meter("test").Int64Counter("events")
+	c := NewCoalescingCounter(counter, nil, WithCoalescingInterval(time.Hour))
+
+	c.Add(5)
+	c.Close()
+	c.Close() // 重复关闭是安全的
+	if got := counterValue(t, reader, "events"); got != 5 {
+		t.Errorf("value after Close = %d, want 5", got)
+	}
+}
+
+func TestCoalescingCounterConcurrentAdds(t *testing.T) {
+	reader, mp := newTestCounter(t)
+	counter, _ := mp.Meter("test").Int64Counter("events")
+	c := NewCoalescingCounter(counter, nil, WithCoalescingInterval(time.Millisecond), WithCoalescingThreshold(100))
+
+	const workers, perWorker = 8, 10000
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWorker {
+				c.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	c.Close()
+
+	// 定时刷新、阈值刷新与 Close 并发进行时，增量既不丢失也不重复
+	if got := counterValue(t, reader, "events"); got != workers*perWorker {
+		t.Errorf("value = %d, want %d", got, workers*perWorker)
+	}
+}
+
+var benchAttrs = []attribute.KeyValue{
+	attribute.String("service", "bench"),
+	attribute.String("route", "/api/v1/items"),
+	attribute.Int("status", 200),
+}
+
+func BenchmarkCoalescingCounterAdd(b *testing.B) {
+	_, mp := newTestCounter(b)
+	counter, _ := mp.Meter("bench").Int64Counter("events")
+	c := NewCoalescingCounter(counter, benchAttrs)
+	defer c.Close()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Add(1)
+		}
+	})
+}
+
+func BenchmarkInt64CounterAdd(b *testing.B) {
+	_, mp := newTestCounter(b)
+	counter, _ := mp.Meter("bench").Int64Counter("events")
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Add(ctx, 1, metric.WithAttributes(benchAttrs...))
+		}
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // Config holds the configuration for telemetry setup
@@ -13,30 +15,140 @@ type Config struct {
 	ServiceName string
 	// 服务版本
 	ServiceVersion string
+	// 构建提交（为空时从构建信息的 VCS 设置中读取）
+	BuildCommit string
+	// 构建日期
+	BuildDate string
+	// 服务命名空间（用于按团队/系统分组服务）
+	ServiceNamespace string
+	// 服务实例 ID（为空时回退到 ResourceAttributes 或 hostname-pid）
+	ServiceInstanceID string
 	// 环境（dev, staging, prod, etc.）
 	Environment string
 	// 额外的资源属性
 	ResourceAttributes map[string]string
+	// 从云元数据服务检测 cloud.* 资源属性（gcp、aws、azure、auto，为空时不检测）
+	CloudDetector string
 	// OTLP 导出器端点
 	OTLPEndpoint string
+	// OTLP 传输协议（grpc 或 http/protobuf，为空时按端点推断：带 scheme 的 URL 使用 HTTP，裸 host:port 使用 gRPC）
+	OTLPProtocol string
+	// OTLP 建立连接的超时时间（仅约束初始拨号，为 0 时使用 5s）
+	OTLPDialTimeout time.Duration
+	// NewProvider 启动前是否检查 OTLP collector 可达（不可达时返回错误而非在导出时才失败）
+	PrecheckEndpoint bool
+	// OTLP 单次导出的超时时间（约束每一批数据的发送）
+	OTLPExportTimeout time.Duration
+	// OTLP gRPC 连接的 keepalive 间隔（0 表示不启用）
+	OTLPKeepalive time.Duration
+	// OTLP gRPC 单条消息的最大字节数（0 表示使用 gRPC 默认值）
+	OTLPMaxMessageSize int
+	// OTLP 导出使用的 bearer token 来源，每次导出前调用以获取最新 token（为 nil 时不附加认证头）
+	// 使用 oauth2.TokenSource 时可包装为 func() (string, error) { t, err := ts.Token(); ... return t.AccessToken, nil }
+	OTLPTokenSource func() (string, error)
+	// 导出失败时落盘 span 的目录，连接恢复后按时间顺序重放（为空时不落盘）
+	SpoolDir string
+	// 落盘文件的最大总字节数，超出时删除最旧的文件
+	SpoolMaxBytes int
+	// 落盘文件的保留时长，超过该时长的文件不再重放
+	SpoolRetention time.Duration
 	// 是否启用控制台导出器
 	EnableConsoleExporter bool
+	// 控制台导出器是否输出单行 JSON（每个 span/指标批次一行，便于 grep 与日志采集；零值输出多行缩进的 JSON）
+	ConsoleExporterCompact bool
+	// 关闭时以 Chrome Trace Event JSON 写入 span 的文件路径，可在 Perfetto/chrome://tracing 中打开（为空时不写入）
+	PerfettoExportPath string
 	// 批处理的时间间隔
 	BatchTimeout time.Duration
 	// 批处理的最大导出大小
 	MaxExportBatchSize int
+	// 批处理队列满时是否阻塞调用方（默认丢弃 span；阻塞可避免丢数据但会增加调用方延迟）
+	BlockOnQueueFull bool
+	// 是否在导出前丢弃耗时不超过 MinSpanDuration 的 span（如有缺陷的插桩产生的零耗时 span）
+	DropZeroDurationSpans bool
+	// DropZeroDurationSpans 启用时 span 的最小耗时（默认 0，即只丢弃零耗时 span）
+	MinSpanDuration time.Duration
+	// 是否从带 peer.service 属性的客户端 span 记录服务依赖图，通过 Provider.DependencyGraphHandler 查看
+	EnableDependencyGraph bool
+	// 在内存中保留的最近结束的 span 数量，通过 Provider.RecentSpansHandler 查看（为 0 时不保留）
+	DebugSpanBufferSize int
 	// 采样率 (0.0-1.0)
 	SamplingRatio float64
+	// 是否基于父 span 的采样决定（父 span 存在时沿用其决定，仅根 span 按 SamplingRatio 采样）
+	ParentBasedSampling bool
+	// 采样谓词，任一谓词命中 span 起始属性时强制采样
+	SamplePredicates []AttributePredicate
+	// 永不采样的 span 名称（如内部轮询），优先于其他采样规则
+	NeverSampleSpanNames []string
+	// 按 span 名称设置的采样比例，覆盖该名称的 SamplingRatio；永不采样列表、sampling.priority、强制采样与谓词仍然优先
+	OperationSamplingRatios map[string]float64
 	// 是否启用 metric 导出
 	EnableMetrics bool
 	// 是否启用 log 导出
 	EnableLogs bool
+	// 日志级别（debug、info、warn、error，为空时按 Environment 决定）
+	LogLevel string
+	// 日志输出目标（stdout、stderr、file、syslog，为空或不支持时使用 stdout）
+	LogSink string
+	// LogSink 为 file 时的日志文件路径
+	LogFilePath string
+	// LogSink 为 syslog 时使用的 facility（如 user、daemon、local0-local7）
+	SyslogFacility string
+	// 是否将日志采样与 trace 采样绑定（未采样 trace 中丢弃低级别日志）
+	SampleLogsWithTrace bool
+	// 未采样 trace 中保留的最低日志级别（最高为 warn，warn/error 始终保留）
+	UnsampledLogLevel string
+	// 是否在 span 结束时将其事件额外作为关联到该 span 的日志记录经 OTel 日志 API 发送，便于在日志后端中检索
+	SpanEventsAsLogs bool
+	// 错误日志文件路径（设置后 Error 及以上级别额外写入该文件）
+	ErrorLogPath string
+	// LoggerWithContext 从 baggage 中读取并添加为日志字段的键（如 tenant.id）
+	LogBaggageKeys []string
+	// 是否按 trace 缓存低于日志级别的日志（如 debug），仅在该 trace 出现 Error 日志时输出，本地根 span 结束时丢弃
+	TraceScopedDebugBuffer bool
+	// 每个 trace 最多缓存的日志条数，超出时丢弃最旧的条目
+	TraceDebugBufferSize int
+	// 同时缓存的最大 trace 数，超出时淘汰最早的 trace；缓存上限为 TraceDebugMaxTraces * TraceDebugBufferSize 条日志
+	TraceDebugMaxTraces int
 	// Metric 收集间隔
 	MetricCollectionInterval time.Duration
+	// 按 instrument 类型配置的聚合时间性（键为 counter、updowncounter、histogram、gauge、
+	// observablecounter、observableupdowncounter、observablegauge，值为 delta 或 cumulative；未配置的类型使用 cumulative）
+	TemporalityByKind map[string]string
+	// 是否启用 Go runtime 指标
+	EnableRuntimeMetrics bool
+	// 是否启用 Prometheus 导出器（拉模式）
+	EnablePrometheusExporter bool
+	// Prometheus 指标名前缀
+	PrometheusNamespace string
+	// 附加到所有 Prometheus 序列上的常量标签（如 cluster、region）
+	PrometheusConstLabels map[string]string
 	// TLS 配置
 	TLSConfig TLSConfig
 	// 重试配置
 	RetryConfig RetryConfig
+	// 需要从 baggage 复制到 span 属性的键
+	CopyBaggageToAttributes []string
+	// 是否从 downward API 环境变量（POD_NAME、POD_NAMESPACE、NODE_NAME）为 span 添加 k8s 属性
+	EnableK8sSpanEnrichment bool
+	// 是否通过 telemetry_active_spans 指标跟踪尚未结束的 span 数量（用于排查遗漏的 span.End()）
+	TrackActiveSpans bool
+	// 是否通过 telemetry_oldest_unexported_span_age_seconds 指标跟踪最早未导出 span 的等待时长
+	TrackUnexportedSpanAge bool
+	// 是否在 WithSpanAllocs 中统计内存分配（会短暂 stop-the-world，仅用于性能分析）
+	EnableSpanAllocs bool
+	// 应用属性的命名空间，非语义约定的 span 属性键在导出前加上该前缀（如 data.id -> acme.data.id）
+	AttributeNamespace string
+	// 附加到每个 span 上的默认属性（以 span 属性而非资源属性的形式出现）
+	DefaultSpanAttributes map[string]string
+	// WithSpan 使用的错误分类器（为空时使用 DefaultErrorClassifier）
+	ErrorClassifier ErrorClassifier
+	// trace/span ID 生成器（为空时使用 SDK 的随机生成器；测试中可使用 NewSequentialIDGenerator 获得可预测的 ID）
+	IDGenerator sdktrace.IDGenerator
+	// ContextWithSpan/WithSpan 默认使用的 tracer 名称（为空时使用 ServiceName）
+	TracerName string
+	// Tracer/Meter 默认使用的 instrumentation scope 版本（为空时使用 ServiceVersion）
+	InstrumentationVersion string
 }
 
 // TLSConfig holds TLS/mTLS configuration
@@ -73,19 +185,59 @@ type RetryConfig struct {
 
 // DefaultConfig returns a default configuration
 func DefaultConfig() Config {
-	return Config{
+	cfg := Config{
 		ServiceName:              getEnv("OTEL_SERVICE_NAME", "optl-service"),
 		ServiceVersion:           getEnv("OTEL_SERVICE_VERSION", "v0.1.0"),
+		BuildCommit:              getEnv("OTEL_BUILD_COMMIT", ""),
+		BuildDate:                getEnv("OTEL_BUILD_DATE", ""),
+		ServiceNamespace:         getEnv("OTEL_SERVICE_NAMESPACE", ""),
+		ServiceInstanceID:        getEnv("OTEL_SERVICE_INSTANCE_ID", ""),
 		Environment:              getEnv("OTEL_ENVIRONMENT", "development"),
 		ResourceAttributes:       parseResourceAttributes(getEnv("OTEL_RESOURCE_ATTRIBUTES", "")),
+		CloudDetector:            getEnv("OTEL_CLOUD_DETECTOR", ""),
 		OTLPEndpoint:             getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317"),
+		OTLPProtocol:             getEnv("OTEL_EXPORTER_OTLP_PROTOCOL", ""),
+		OTLPDialTimeout:          getEnvDuration("OTEL_OTLP_DIAL_TIMEOUT", 5*time.Second),
+		PrecheckEndpoint:         getEnvBool("OTEL_PRECHECK_ENDPOINT", false),
+		OTLPExportTimeout:        getEnvDuration("OTEL_OTLP_EXPORT_TIMEOUT", 10*time.Second),
+		OTLPKeepalive:            getEnvDuration("OTEL_OTLP_KEEPALIVE", 0),
+		OTLPMaxMessageSize:       getEnvInt("OTEL_OTLP_MAX_MESSAGE_SIZE", 0),
+		SpoolDir:                 getEnv("OTEL_SPOOL_DIR", ""),
+		SpoolMaxBytes:            getEnvInt("OTEL_SPOOL_MAX_BYTES", 100*1024*1024),
+		SpoolRetention:           getEnvDuration("OTEL_SPOOL_RETENTION", 24*time.Hour),
 		EnableConsoleExporter:    getEnvBool("OTEL_ENABLE_CONSOLE_EXPORTER", true),
+		ConsoleExporterCompact:   getEnvBool("OTEL_CONSOLE_EXPORTER_COMPACT", false),
+		PerfettoExportPath:       getEnv("OTEL_PERFETTO_EXPORT_PATH", ""),
 		BatchTimeout:             getEnvDuration("OTEL_BATCH_TIMEOUT", 5*time.Second),
 		MaxExportBatchSize:       getEnvInt("OTEL_MAX_EXPORT_BATCH_SIZE", 512),
+		BlockOnQueueFull:         getEnvBool("OTEL_BLOCK_ON_QUEUE_FULL", false),
+		DropZeroDurationSpans:    getEnvBool("OTEL_DROP_ZERO_DURATION_SPANS", false),
+		MinSpanDuration:          getEnvDuration("OTEL_MIN_SPAN_DURATION", 0),
+		DebugSpanBufferSize:      getEnvInt("OTEL_DEBUG_SPAN_BUFFER_SIZE", 0),
+		EnableDependencyGraph:    getEnvBool("OTEL_ENABLE_DEPENDENCY_GRAPH", false),
 		SamplingRatio:            getEnvFloat("OTEL_SAMPLING_RATIO", 1.0),
+		NeverSampleSpanNames:     getEnvList("OTEL_NEVER_SAMPLE_SPAN_NAMES"),
+		OperationSamplingRatios:  getEnvRatios("OTEL_OPERATION_SAMPLING_RATIOS"),
 		EnableMetrics:            getEnvBool("OTEL_ENABLE_METRICS", true),
 		EnableLogs:               getEnvBool("OTEL_ENABLE_LOGS", true),
+		LogLevel:                 getEnv("OTEL_LOG_LEVEL", ""),
+		LogSink:                  getEnv("OTEL_LOG_SINK", "stdout"),
+		LogFilePath:              getEnv("OTEL_LOG_FILE_PATH", ""),
+		SyslogFacility:           getEnv("OTEL_SYSLOG_FACILITY", "local0"),
+		SampleLogsWithTrace:      getEnvBool("OTEL_SAMPLE_LOGS_WITH_TRACE", false),
+		UnsampledLogLevel:        getEnv("OTEL_UNSAMPLED_LOG_LEVEL", "warn"),
+		SpanEventsAsLogs:         getEnvBool("OTEL_SPAN_EVENTS_AS_LOGS", false),
+		ErrorLogPath:             getEnv("OTEL_ERROR_LOG_PATH", ""),
+		LogBaggageKeys:           getEnvList("OTEL_LOG_BAGGAGE_KEYS"),
+		TraceScopedDebugBuffer:   getEnvBool("OTEL_TRACE_SCOPED_DEBUG_BUFFER", false),
+		TraceDebugBufferSize:     getEnvInt("OTEL_TRACE_DEBUG_BUFFER_SIZE", 256),
+		TraceDebugMaxTraces:      getEnvInt("OTEL_TRACE_DEBUG_MAX_TRACES", 1024),
 		MetricCollectionInterval: getEnvDuration("OTEL_METRIC_COLLECTION_INTERVAL", 10*time.Second),
+		TemporalityByKind:        parseResourceAttributes(getEnv("OTEL_METRIC_TEMPORALITY_BY_KIND", "")),
+		EnableRuntimeMetrics:     getEnvBool("OTEL_ENABLE_RUNTIME_METRICS", true),
+		EnablePrometheusExporter: getEnvBool("OTEL_ENABLE_PROMETHEUS_EXPORTER", false),
+		PrometheusNamespace:      getEnv("OTEL_PROMETHEUS_NAMESPACE", ""),
+		PrometheusConstLabels:    parseResourceAttributes(getEnv("OTEL_PROMETHEUS_CONST_LABELS", "")),
 		TLSConfig: TLSConfig{
 			Enabled:             getEnvBool("OTEL_TLS_ENABLED", false),
 			MTLSEnabled:         getEnvBool("OTEL_MTLS_ENABLED", false),
@@ -102,6 +254,42 @@ func DefaultConfig() Config {
 			Multiplier:            getEnvFloat("OTEL_RETRY_MULTIPLIER", 1.5),
 			RandomizationFactor:   getEnvFloat("OTEL_RETRY_RANDOMIZATION_FACTOR", 0.5),
 		},
+		CopyBaggageToAttributes: getEnvList("OTEL_COPY_BAGGAGE_TO_ATTRIBUTES"),
+		TracerName:              getEnv("OTEL_TRACER_NAME", ""),
+		InstrumentationVersion:  getEnv("OTEL_INSTRUMENTATION_VERSION", ""),
+		TrackActiveSpans:        getEnvBool("OTEL_TRACK_ACTIVE_SPANS", false),
+		TrackUnexportedSpanAge:  getEnvBool("OTEL_TRACK_UNEXPORTED_SPAN_AGE", false),
+		EnableSpanAllocs:        getEnvBool("OTEL_ENABLE_SPAN_ALLOCS", false),
+		EnableK8sSpanEnrichment: getEnvBool("OTEL_ENABLE_K8S_SPAN_ENRICHMENT", false),
+		AttributeNamespace:      getEnv("OTEL_ATTRIBUTE_NAMESPACE", ""),
+		DefaultSpanAttributes:   parseResourceAttributes(getEnv("OTEL_DEFAULT_SPAN_ATTRIBUTES", "")),
+	}
+	applyTracesSamplerEnv(&cfg)
+	return cfg
+}
+
+// applyTracesSamplerEnv 按 OTel 规范解析 OTEL_TRACES_SAMPLER/OTEL_TRACES_SAMPLER_ARG，
+// 设置时覆盖 OTEL_SAMPLING_RATIO；不支持的采样器（如 jaeger_remote、xray）被忽略
+func applyTracesSamplerEnv(cfg *Config) {
+	name, exists := os.LookupEnv("OTEL_TRACES_SAMPLER")
+	if !exists {
+		return
+	}
+	ratio := getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1.0)
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "always_on":
+		cfg.SamplingRatio, cfg.ParentBasedSampling = 1.0, false
+	case "always_off":
+		cfg.SamplingRatio, cfg.ParentBasedSampling = 0.0, false
+	case "traceidratio":
+		cfg.SamplingRatio, cfg.ParentBasedSampling = ratio, false
+	case "parentbased_always_on":
+		cfg.SamplingRatio, cfg.ParentBasedSampling = 1.0, true
+	case "parentbased_always_off":
+		cfg.SamplingRatio, cfg.ParentBasedSampling = 0.0, true
+	case "parentbased_traceidratio":
+		cfg.SamplingRatio, cfg.ParentBasedSampling = ratio, true
 	}
 }
 
@@ -151,6 +339,26 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvList 获取逗号分隔的列表类型环境变量
+func getEnvList(key string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return nil
+	}
+	return splitList(value)
+}
+
+// splitList 按逗号拆分列表并去除空白项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // parseResourceAttributes 解析资源属性字符串（key1=value1,key2=value2）
 func parseResourceAttributes(attributesStr string) map[string]string {
 	attributes := make(map[string]string)
@@ -168,6 +376,28 @@ func parseResourceAttributes(attributesStr string) map[string]string {
 	return attributes
 }
 
+// getEnvRatios 获取 "name=0.1,name2=0.5" 格式的比例映射类型环境变量，无法解析时返回 nil
+func getEnvRatios(key string) map[string]float64 {
+	ratios, err := parseRatios(os.Getenv(key))
+	if err != nil {
+		return nil
+	}
+	return ratios
+}
+
+// parseRatios 解析 "name=0.1,name2=0.5" 格式的比例映射
+func parseRatios(value string) (map[string]float64, error) {
+	ratios := make(map[string]float64)
+	for name, raw := range parseResourceAttributes(value) {
+		ratio, err := parseFloatEnv(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ratio for %q: %w", name, err)
+		}
+		ratios[strings.TrimSpace(name)] = ratio
+	}
+	return ratios, nil
+}
+
 // 解析整数环境变量
 func parseIntEnv(value string) (int, error) {
 	var intValue int
@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTracesSamplerEnv(t *testing.T) {
+	tests := []struct {
+		sampler     string
+		arg         string
+		ratio       float64
+		parentBased bool
+	}{
+		{sampler: "always_on", ratio: 1.0},
+		{sampler: "always_off", ratio: 0.0},
+		{sampler: "traceidratio", arg: "0.25", ratio: 0.25},
+		{sampler: "traceidratio", ratio: 1.0},
+		{sampler: "parentbased_always_on", ratio: 1.0, parentBased: true},
+		{sampler: "parentbased_always_off", ratio: 0.0, parentBased: true},
+		{sampler: "parentbased_traceidratio", arg: "0.1", ratio: 0.1, parentBased: true},
+		{sampler: " ParentBased_TraceIdRatio ", arg: "0.5", ratio: 0.5, parentBased: true},
+		// 不支持的采样器被忽略，沿用 OTEL_SAMPLING_RATIO
+		{sampler: "jaeger_remote", arg: "0.9", ratio: 0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sampler, func(t *testing.T) {
+			// OTEL_TRACES_SAMPLER 优先于 OTEL_SAMPLING_RATIO
+			t.Setenv("OTEL_SAMPLING_RATIO", "0.3")
+			t.Setenv("OTEL_TRACES_SAMPLER", tt.sampler)
+			if tt.arg != "" {
+				t.Setenv("OTEL_TRACES_SAMPLER_ARG", tt.arg)
+			}
+
+			cfg := DefaultConfig()
+			if cfg.SamplingRatio != tt.ratio || cfg.ParentBasedSampling != tt.parentBased {
+				t.Errorf("SamplingRatio, ParentBasedSampling = %v, %v, want %v, %v",
+					cfg.SamplingRatio, cfg.ParentBasedSampling, tt.ratio, tt.parentBased)
+			}
+		})
+	}
+}
+
+func TestTracesSamplerEnvUnset(t *testing.T) {
+	t.Setenv("OTEL_SAMPLING_RATIO", "0.3")
+	// 先经 t.Setenv 登记恢复，再取消设置
+	t.Setenv("OTEL_TRACES_SAMPLER", "")
+	os.Unsetenv("OTEL_TRACES_SAMPLER")
+	cfg := DefaultConfig()
+	if cfg.SamplingRatio != 0.3 || cfg.ParentBasedSampling {
+		t.Errorf("SamplingRatio, ParentBasedSampling = %v, %v, want 0.3, false", cfg.SamplingRatio, cfg.ParentBasedSampling)
+	}
+}
@@ -2,23 +2,134 @@ package telemetry
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
 
-// ContextWithSpan 创建带有 span 的上下文
+// spanDefaultsKey 上下文中默认 span 属性的键
+type spanDefaultsKey struct{}
+
+// WithSpanDefaults 在上下文中保存默认属性，之后通过 ContextWithSpan/WithSpan 创建的 span 都会带上这些属性
+// 仅在进程内生效，不会像 baggage 一样跨进程传播；嵌套调用会在已有默认属性上追加
+func WithSpanDefaults(ctx context.Context, attrs ...attribute.KeyValue) context.Context {
+	existing := SpanDefaults(ctx)
+	merged := make([]attribute.KeyValue, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, spanDefaultsKey{}, merged)
+}
+
+// SpanDefaults 返回上下文中保存的默认 span 属性
+func SpanDefaults(ctx context.Context) []attribute.KeyValue {
+	attrs, _ := ctx.Value(spanDefaultsKey{}).([]attribute.KeyValue)
+	return attrs
+}
+
+// tracerNameKey 上下文中 tracer 名称的键
+type tracerNameKey struct{}
+
+// defaultTracerName 未在上下文中指定 tracer 名称时使用的默认名称
+var defaultTracerName atomic.Value
+
+func init() {
+	defaultTracerName.Store("")
+}
+
+// SetDefaultTracerName 设置 ContextWithSpan/WithSpan 等函数默认使用的 tracer（instrumentation scope）名称
+func SetDefaultTracerName(name string) {
+	defaultTracerName.Store(name)
+}
+
+// WithTracerName 在上下文中保存 tracer 名称，之后通过 ContextWithSpan/WithSpan/GoWithSpan 等创建的 span
+// 都使用该名称的 tracer，便于在后端区分产生 span 的组件
+func WithTracerName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, tracerNameKey{}, name)
+}
+
+// TracerName 返回上下文中的 tracer 名称，未设置时返回默认名称
+func TracerName(ctx context.Context) string {
+	if name, ok := ctx.Value(tracerNameKey{}).(string); ok && name != "" {
+		return name
+	}
+	return defaultTracerName.Load().(string)
+}
+
+// ContextWithSpan 创建带有 span 的上下文，tracer 名称取自上下文（见 WithTracerName）
+// span 类型默认为 SpanKindInternal，服务入口与出站调用应使用 ContextWithServerSpan/ContextWithClientSpan，
+// 或通过 trace.WithSpanKind 选项指定
 func ContextWithSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
-	return Tracer("").Start(ctx, name, opts...)
+	return ContextWithSpanNamed(ctx, TracerName(ctx), name, opts...)
+}
+
+// ContextWithServerSpan 创建 SpanKindServer 类型的 span，用于未经 HTTP/gRPC 中间件的服务入口（如消息消费、自定义协议）
+func ContextWithServerSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return ContextWithSpan(ctx, name, append([]trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindServer)}, opts...)...)
+}
+
+// ContextWithClientSpan 创建 SpanKindClient 类型的 span，用于未经插桩客户端的出站调用（如数据库、自定义协议）
+func ContextWithClientSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return ContextWithSpan(ctx, name, append([]trace.SpanStartOption{trace.WithSpanKind(trace.SpanKindClient)}, opts...)...)
+}
+
+// ContextWithSpanNamed 使用指定名称的 tracer 创建带有 span 的上下文
+// 返回的上下文同时记录该 tracer 名称，因此其中创建的子 span 沿用同一 instrumentation scope
+func ContextWithSpanNamed(ctx context.Context, tracerName, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if defaults := SpanDefaults(ctx); len(defaults) > 0 {
+		// 默认属性放在最前，调用方显式传入的同名属性优先
+		opts = append([]trace.SpanStartOption{trace.WithAttributes(defaults...)}, opts...)
+	}
+	if tracerName != TracerName(ctx) {
+		ctx = WithTracerName(ctx, tracerName)
+	}
+	return Tracer(tracerName).Start(ctx, spanName, opts...)
+}
+
+// ContextWithSpanAndBaggage 将键值同时设置为 baggage（随请求传播）和新 span 的属性（可查询）
+// 非法的 baggage 键会被跳过并记录警告
+func ContextWithSpanAndBaggage(ctx context.Context, name string, members map[string]string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	bag := baggage.FromContext(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(members))
+
+	for key, value := range members {
+		member, err := baggage.NewMemberRaw(key, value)
+		if err == nil {
+			bag, err = bag.SetMember(member)
+		}
+		if err != nil {
+			LoggerWithContext(ctx).Warn("Skipping invalid baggage member",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+			continue
+		}
+		attrs = append(attrs, attribute.String(key, value))
+	}
+
+	ctx = baggage.ContextWithBaggage(ctx, bag)
+	opts = append(opts, trace.WithAttributes(attrs...))
+	return ContextWithSpan(ctx, name, opts...)
 }
 
 // WithSpan 包装函数，创建一个新的 span
 func WithSpan(ctx context.Context, name string, fn func(context.Context) error, opts ...trace.SpanStartOption) error {
+	_, err := WithSpanValue(ctx, name, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	}, opts...)
+	return err
+}
+
+// WithSpanValue 与 WithSpan 相同，但直接返回 fn 的结果，无需在闭包外声明结果变量
+func WithSpanValue[T any](ctx context.Context, name string, fn func(context.Context) (T, error), opts ...trace.SpanStartOption) (T, error) {
 	ctx, span := ContextWithSpan(ctx, name, opts...)
 	defer span.End()
 
@@ -27,12 +138,11 @@ func WithSpan(ctx context.Context, name string, fn func(context.Context) error,
 	logger.Debug("Starting span", zap.String("span_name", name))
 
 	// 执行函数
-	err := fn(ctx)
+	value, err := fn(ctx)
 
 	// 记录错误
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
+		recordSpanError(span, err)
 		logger.Error("Span error",
 			zap.String("span_name", name),
 			zap.Error(err),
@@ -41,7 +151,75 @@ func WithSpan(ctx context.Context, name string, fn func(context.Context) error,
 		logger.Debug("Completed span", zap.String("span_name", name))
 	}
 
-	return err
+	return value, err
+}
+
+// BackgroundSpan 为后台任务创建一个脱离请求生命周期的新根 span
+// 返回的上下文基于 context.Background()，不会随请求上下文取消；新 span 属于新的 trace，
+// 并通过 trace.Link 指向发起它的 span，以便在追踪 UI 中从后台任务跳转回原请求
+func BackgroundSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: sc}))
+	}
+	opts = append(opts, trace.WithNewRoot())
+	return ContextWithSpan(context.Background(), name, opts...)
+}
+
+// ContextWithRemoteSpanContext 将远端 span 上下文注入 ctx，后续创建的 span 将成为其子 span
+// 若 sc 无效（trace ID 或 span ID 为零）则原样返回 ctx
+func ContextWithRemoteSpanContext(ctx context.Context, sc trace.SpanContext) context.Context {
+	if !sc.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// EnsureTraceID 返回 ctx 中的 trace ID，用于在创建第一个 span 之前就让日志带上与之后 trace 一致的关联 ID
+// ctx 中没有有效的 span 上下文时会创建名为 edge 的新根 span，调用方需在请求结束时调用 SpanFromContext(ctx).End()；
+// 未安装 SDK（tracer 为 noop）时改为注入随机生成的远端 span 上下文，后续 span 沿用该 trace ID
+func EnsureTraceID(ctx context.Context) (context.Context, string) {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return ctx, sc.TraceID().String()
+	}
+
+	ctx, span := ContextWithServerSpan(ctx, "edge", trace.WithNewRoot())
+	if sc := span.SpanContext(); sc.IsValid() {
+		return ctx, sc.TraceID().String()
+	}
+
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	_, _ = rand.Read(traceID[:])
+	_, _ = rand.Read(spanID[:])
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Remote:  true,
+	})
+	return trace.ContextWithRemoteSpanContext(ctx, sc), traceID.String()
+}
+
+// ContextWithBatchLinks 为批量消费（如一次 Kafka poll）创建 span，并将每个载体中提取的 span 上下文作为 link
+// 无法提取出有效 span 上下文的载体会被跳过；新 span 的父级仍取自 ctx
+func ContextWithBatchLinks(ctx context.Context, name string, carriers []propagation.TextMapCarrier, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	propagator := otel.GetTextMapPropagator()
+	links := make([]trace.Link, 0, len(carriers))
+	for _, carrier := range carriers {
+		if carrier == nil {
+			continue
+		}
+		sc := trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+		if !sc.IsValid() {
+			continue
+		}
+		links = append(links, trace.Link{SpanContext: sc})
+	}
+
+	opts = append(opts,
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("messaging.batch.message_count", len(carriers))),
+	)
+	return ContextWithSpan(ctx, name, opts...)
 }
 
 // SpanFromContext 从上下文中获取当前的 span
@@ -72,6 +250,19 @@ func AddSpanEvent(ctx context.Context, name string, attributes ...attribute.KeyV
 	}
 }
 
+// SafeAddSpanEvent 向 ctx 中的 span 添加事件，可在多个 goroutine 中并发调用
+// span 已结束（如父 span 先于后台 goroutine 结束）时事件被丢弃且不会报错，返回值表示事件是否被记录；
+// 需要在 span 结束后继续标注时，应在启动 goroutine 前创建子 span 或使用 GoDetached
+func SafeAddSpanEvent(ctx context.Context, name string, attributes ...attribute.KeyValue) bool {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return false
+	}
+	// SDK span 的方法内部加锁，并发调用是安全的；IsRecording 与 AddEvent 之间 span 结束时 AddEvent 为空操作
+	span.AddEvent(name, trace.WithAttributes(attributes...))
+	return true
+}
+
 // AddSpanEventWithTimestamp 向 span 添加带时间戳的事件
 func AddSpanEventWithTimestamp(ctx context.Context, name string, timestamp time.Time, attributes ...attribute.KeyValue) {
 	span := trace.SpanFromContext(ctx)
@@ -88,7 +279,26 @@ func SetSpanAttributes(ctx context.Context, attributes ...attribute.KeyValue) {
 	}
 }
 
+// AddSpanEventFunc 与 AddSpanEvent 相同，但仅在 span 正在记录时才调用 fn 构造属性，
+// 适用于属性构造开销较大的热点路径，未采样的 span 不产生构造开销
+func AddSpanEventFunc(ctx context.Context, name string, fn func() []attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		span.AddEvent(name, trace.WithAttributes(fn()...))
+	}
+}
+
+// SetSpanAttributesFunc 与 SetSpanAttributes 相同，但仅在 span 正在记录时才调用 fn 构造属性
+func SetSpanAttributesFunc(ctx context.Context, fn func() []attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		span.SetAttributes(fn()...)
+	}
+}
+
 // GoWithContext 在 goroutine 中执行函数并传递上下文
+// Go* 系列函数（GoDetached 除外）都会等待 goroutine 完成后才返回，因此调用方的 span 不会先于其结束；
+// 在这些函数之外自行启动、且不等待的 goroutine 中，调用方的 span 可能先结束，之后添加的事件会被丢弃（见 SafeAddSpanEvent）
 func GoWithContext(ctx context.Context, fn func(context.Context) error) error {
 	// 创建 errgroup
 	g, gCtx := errgroup.WithContext(ctx)
@@ -109,6 +319,27 @@ func GoWithSpan(ctx context.Context, name string, fn func(context.Context) error
 	})
 }
 
+// GoDetached 在脱离 ctx 生命周期的 goroutine 中执行 fn，不随 ctx 取消
+// fn 在名为 name 的新根 span 中执行（见 BackgroundSpan），该 span 通过 link 指向 ctx 中的 span；
+// 错误记录到 span 并输出日志。与 GoWithContext 不同，调用方不等待 fn 完成，
+// 调用方的 span 可能在 fn 运行期间结束，fn 中的事件与属性应记录到 detachedCtx 中的新 span 上
+func GoDetached(ctx context.Context, name string, fn func(context.Context) error) {
+	// 在调用方 goroutine 中创建 span，确保 link 指向发起时的 span
+	detachedCtx, span := BackgroundSpan(ctx, name)
+
+	go func() {
+		defer span.End()
+
+		if err := fn(detachedCtx); err != nil {
+			recordSpanError(span, err)
+			LoggerWithContext(detachedCtx).Error("Detached goroutine failed",
+				zap.String("span_name", name),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
 // GoForEach 并行执行函数，并传递上下文
 func GoForEach[T any](ctx context.Context, items []T, fn func(context.Context, T) error) error {
 	g, gCtx := errgroup.WithContext(ctx)
@@ -123,24 +354,39 @@ func GoForEach[T any](ctx context.Context, items []T, fn func(context.Context, T
 	return g.Wait()
 }
 
-// GoForEachWithSpan 在带有 span 的 goroutine 中并行执行函数
-func GoForEachWithSpan[T any](ctx context.Context, name string, items []T, fn func(context.Context, T) error) error {
-	g, gCtx := errgroup.WithContext(ctx)
+// ErrorMode 决定带 span 的 Go* 函数如何处理单个条目返回的错误
+type ErrorMode int
 
-	for i, item := range items {
-		i, item := i, item // 创建闭包变量副本
-		g.Go(func() error {
-			spanName := fmt.Sprintf("%s-%d", name, i)
-			return WithSpan(gCtx, spanName, func(spanCtx context.Context) error {
-				return fn(spanCtx, item)
-			})
-		})
+const (
+	// ErrorModePropagate 第一个错误取消其余条目并由函数返回（默认）
+	ErrorModePropagate ErrorMode = iota
+	// ErrorModeRecordOnly 错误只记录到该条目的 span 并输出日志，不取消其余条目，函数返回 nil；
+	// 父 span 带有 batch.error_count 属性。需要汇总错误时应自行在 fn 中收集
+	ErrorModeRecordOnly
+)
+
+// GoOption 配置带 span 的 Go* 函数的选项
+type GoOption func(*goOptions)
+
+type goOptions struct {
+	errorMode ErrorMode
+}
+
+// WithErrorMode 设置条目错误的处理方式（默认 ErrorModePropagate）
+func WithErrorMode(mode ErrorMode) GoOption {
+	return func(o *goOptions) {
+		o.errorMode = mode
 	}
+}
 
-	return g.Wait()
+// GoForEachWithSpan 在带有 span 的 goroutine 中并行执行函数
+// 整个分发包裹在名为 name 的父 span 中，父 span 与各子 span 带有相同的 batch.dispatch_id 与 batch.item_count，子 span 另带 batch.index 属性
+func GoForEachWithSpan[T any](ctx context.Context, name string, items []T, fn func(context.Context, T) error, opts ...GoOption) error {
+	return goWithSpans(ctx, name, 0, items, fn, opts...)
 }
 
 // GoWithLimit 限制并行数量并传递上下文
+// 不创建 span（没有用于命名的操作名称），条目继承 ctx 中的 span；需要批次父 span 与条目子 span 时使用 GoWithLimitAndSpan
 func GoWithLimit[T any](ctx context.Context, concurrency int, items []T, fn func(context.Context, T) error) error {
 	g, gCtx := errgroup.WithContext(ctx)
 	g.SetLimit(concurrency)
@@ -156,17 +402,204 @@ func GoWithLimit[T any](ctx context.Context, concurrency int, items []T, fn func
 }
 
 // GoWithLimitAndSpan 在带有 span 的 goroutine 中限制并行数量
-func GoWithLimitAndSpan[T any](ctx context.Context, name string, concurrency int, items []T, fn func(context.Context, T) error) error {
+// span 结构与 GoForEachWithSpan 相同，父 span 额外带有 batch.concurrency 属性
+func GoWithLimitAndSpan[T any](ctx context.Context, name string, concurrency int, items []T, fn func(context.Context, T) error, opts ...GoOption) error {
+	return goWithSpans(ctx, name, concurrency, items, fn, opts...)
+}
+
+// goWithSpans 在父 span 下为每个条目创建名为 name-i 的子 span 并发执行，concurrency <= 0 表示不限制并行数量
+func goWithSpans[T any](ctx context.Context, name string, concurrency int, items []T, fn func(context.Context, T) error, opts ...GoOption) error {
+	var options goOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	dispatchID := newDispatchID()
+	dispatchAttrs := []attribute.KeyValue{
+		attribute.String("batch.dispatch_id", dispatchID),
+		attribute.Int("batch.item_count", len(items)),
+	}
+	// 父 span 额外记录并发配置，便于排查吞吐问题
+	var batchAttrs []attribute.KeyValue
+	if concurrency > 0 {
+		batchAttrs = append(batchAttrs, attribute.Int("batch.concurrency", concurrency))
+	}
+
+	return WithSpan(ctx, name, func(ctx context.Context) error {
+		g, gCtx := errgroup.WithContext(ctx)
+		if concurrency > 0 {
+			g.SetLimit(concurrency)
+		}
+
+		var errorCount atomic.Int64
+		for i, item := range items {
+			i, item := i, item // 创建闭包变量副本
+			g.Go(func() error {
+				spanName := fmt.Sprintf("%s-%d", name, i)
+				err := WithSpan(gCtx, spanName, func(spanCtx context.Context) error {
+					return fn(spanCtx, item)
+				}, trace.WithAttributes(dispatchAttrs...), trace.WithAttributes(attribute.Int("batch.index", i)))
+				// WithSpan 已将错误记录到条目 span 并输出日志
+				if err != nil && options.errorMode == ErrorModeRecordOnly {
+					errorCount.Add(1)
+					return nil
+				}
+				return err
+			})
+		}
+
+		err := g.Wait()
+		if options.errorMode == ErrorModeRecordOnly {
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("batch.error_count", errorCount.Load()))
+		}
+		return err
+	}, trace.WithAttributes(dispatchAttrs...), trace.WithAttributes(batchAttrs...))
+}
+
+// FanIn 并发执行 producers 并按顺序汇总结果，与 GoForEach 系列的扇出相对应
+// 整个汇总过程包装在名为 name 的父 span 中，每个 producer 在名为 name-i 的子 span 中执行，
+// 全部完成后父 span 添加指向各 producer span 的链接；任一 producer 出错时取消其余 producer 并返回第一个错误，
+// 此时结果中未完成的位置为零值
+func FanIn[T any](ctx context.Context, name string, producers []func(context.Context) (T, error)) ([]T, error) {
+	results := make([]T, len(producers))
+
+	err := WithSpan(ctx, name, func(ctx context.Context) error {
+		g, gCtx := errgroup.WithContext(ctx)
+		producerSpans := make([]trace.SpanContext, len(producers))
+
+		for i, produce := range producers {
+			i, produce := i, produce // 创建闭包变量副本
+			g.Go(func() error {
+				spanName := fmt.Sprintf("%s-%d", name, i)
+				result, err := WithSpanValue(gCtx, spanName, func(spanCtx context.Context) (T, error) {
+					producerSpans[i] = trace.SpanContextFromContext(spanCtx)
+					return produce(spanCtx)
+				}, trace.WithAttributes(attribute.Int("fanin.index", i)))
+				if err != nil {
+					return err
+				}
+				results[i] = result
+				return nil
+			})
+		}
+		err := g.Wait()
+
+		span := trace.SpanFromContext(ctx)
+		for i, sc := range producerSpans {
+			if sc.IsValid() {
+				span.AddLink(trace.Link{
+					SpanContext: sc,
+					Attributes:  []attribute.KeyValue{attribute.Int("fanin.index", i)},
+				})
+			}
+		}
+		return err
+	}, trace.WithAttributes(attribute.Int("fanin.producer_count", len(producers))))
+
+	return results, err
+}
+
+// GoWithLimitAndSpanFlush 与 GoWithLimitAndSpan 相同，但返回前强制刷新 tracer provider，
+// 确保短生命周期的批处理任务在快速退出时不会丢失已完成条目的 span
+func GoWithLimitAndSpanFlush[T any](ctx context.Context, name string, concurrency int, items []T, fn func(context.Context, T) error, opts ...GoOption) error {
+	err := GoWithLimitAndSpan(ctx, name, concurrency, items, fn, opts...)
+
+	if flushErr := ForceFlushTraces(ctx); flushErr != nil {
+		if err != nil {
+			LoggerWithContext(ctx).Warn("Failed to flush spans after batch failure", zap.Error(flushErr))
+			return err
+		}
+		return fmt.Errorf("failed to flush spans: %w", flushErr)
+	}
+	return err
+}
+
+// ForceFlushTraces 强制导出全局 tracer provider 中缓冲的 span
+// 即使 ctx 已被取消也会在限定时间内完成刷新
+func ForceFlushTraces(ctx context.Context) error {
+	flusher, ok := otel.GetTracerProvider().(interface {
+		ForceFlush(context.Context) error
+	})
+	if !ok {
+		return nil
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	return flusher.ForceFlush(flushCtx)
+}
+
+// BatchOption 配置 BatchProcess 的选项
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	concurrency int
+}
+
+// WithBatchConcurrency 设置批次并行处理的最大数量（默认顺序处理）
+func WithBatchConcurrency(concurrency int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// BatchProcess 将 items 按 batchSize 分块，每个分块在名为 batch-N 的 span 中处理
+// 默认按顺序处理分块，可通过 WithBatchConcurrency 开启有界并行；出错时记录失败的批次
+func BatchProcess[T any](ctx context.Context, items []T, batchSize int, fn func(context.Context, []T) error, opts ...BatchOption) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("invalid batch size %d", batchSize)
+	}
+
+	options := batchOptions{concurrency: 1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// 切分批次
+	var batches [][]T
+	for start := 0; start < len(items); start += batchSize {
+		end := start + batchSize
+		if end > len(items) {
+			end = len(items)
+		}
+		batches = append(batches, items[start:end])
+	}
+
+	processBatch := func(ctx context.Context, i int, batch []T) error {
+		err := WithSpan(ctx, fmt.Sprintf("batch-%d", i), func(spanCtx context.Context) error {
+			return fn(spanCtx, batch)
+		}, trace.WithAttributes(
+			attribute.Int("batch.index", i),
+			attribute.Int("batch.size", len(batch)),
+		))
+		if err != nil {
+			AddSpanEvent(ctx, "batch_failed",
+				attribute.Int("batch.index", i),
+				attribute.Int("batch.size", len(batch)),
+			)
+			return fmt.Errorf("batch %d failed: %w", i, err)
+		}
+		return nil
+	}
+
+	// 顺序处理
+	if options.concurrency <= 1 {
+		for i, batch := range batches {
+			if err := processBatch(ctx, i, batch); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 有界并行处理
 	g, gCtx := errgroup.WithContext(ctx)
-	g.SetLimit(concurrency)
+	g.SetLimit(options.concurrency)
 
-	for i, item := range items {
-		i, item := i, item // 创建闭包变量副本
+	for i, batch := range batches {
+		i, batch := i, batch // 创建闭包变量副本
 		g.Go(func() error {
-			spanName := fmt.Sprintf("%s-%d", name, i)
-			return WithSpan(gCtx, spanName, func(spanCtx context.Context) error {
-				return fn(spanCtx, item)
-			})
+			return processBatch(gCtx, i, batch)
 		})
 	}
 
@@ -0,0 +1,631 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestGoWithLimitAndSpanNestsItemSpans(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	err := GoWithLimitAndSpan(context.Background(), "batch", 2, []int{1, 2, 3}, func(ctx context.Context, item int) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	parent, ok := findSpan(spans, "batch")
+	if !ok {
+		t.Fatal("batch span not exported")
+	}
+	if v, _ := attrValue(parent.Attributes, "batch.concurrency"); v != "2" {
+		t.Errorf("batch.concurrency = %q, want 2", v)
+	}
+	if v, _ := attrValue(parent.Attributes, "batch.item_count"); v != "3" {
+		t.Errorf("batch.item_count = %q, want 3", v)
+	}
+	for i := range 3 {
+		name := fmt.Sprintf("batch-%d", i)
+		child, ok := findSpan(spans, name)
+		if !ok {
+			t.Errorf("item span %s not exported", name)
+			continue
+		}
+		if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("%s parent = %s, want the batch span %s", name, child.Parent.SpanID(), parent.SpanContext.SpanID())
+		}
+	}
+}
+
+func TestGoWithLimitKeepsCallerSpan(t *testing.T) {
+	exporter := setupTestTracing(t)
+	ctx, caller := Tracer("test").Start(context.Background(), "caller")
+
+	err := GoWithLimit(ctx, 2, []int{1, 2, 3}, func(ctx context.Context, item int) error {
+		if got := trace.SpanContextFromContext(ctx).SpanID(); got != caller.SpanContext().SpanID() {
+			return fmt.Errorf("item %d ran in span %s, want the caller span", item, got)
+		}
+		return nil
+	})
+	caller.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exporter.GetSpans()); n != 1 {
+		t.Errorf("exported %d spans, want only the caller span", n)
+	}
+}
+
+func TestGoForEachWithSpanSharesDispatchID(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	items := []string{"a", "b", "c", "d"}
+	if err := GoForEachWithSpan(context.Background(), "fanout", items, func(context.Context, string) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	parent, ok := findSpan(spans, "fanout")
+	if !ok {
+		t.Fatal("dispatch span not exported")
+	}
+	dispatchID, ok := attrValue(parent.Attributes, "batch.dispatch_id")
+	if !ok || dispatchID == "" {
+		t.Fatal("dispatch span has no batch.dispatch_id")
+	}
+	for i := range items {
+		name := fmt.Sprintf("fanout-%d", i)
+		child, ok := findSpan(spans, name)
+		if !ok {
+			t.Errorf("child span %s not exported", name)
+			continue
+		}
+		if v, _ := attrValue(child.Attributes, "batch.dispatch_id"); v != dispatchID {
+			t.Errorf("%s batch.dispatch_id = %q, want %q", name, v, dispatchID)
+		}
+		if v, _ := attrValue(child.Attributes, "batch.index"); v != fmt.Sprint(i) {
+			t.Errorf("%s batch.index = %q, want %d", name, v, i)
+		}
+		if v, _ := attrValue(child.Attributes, "batch.item_count"); v != "4" {
+			t.Errorf("%s batch.item_count = %q, want 4", name, v)
+		}
+		if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("%s is not nested under the dispatch span", name)
+		}
+	}
+
+	// 每次分发生成新的 ID
+	_ = GoForEachWithSpan(context.Background(), "again", items[:1], func(context.Context, string) error { return nil })
+	again, _ := findSpan(exporter.GetSpans(), "again")
+	if v, _ := attrValue(again.Attributes, "batch.dispatch_id"); v == dispatchID {
+		t.Error("two dispatches share a dispatch ID")
+	}
+}
+
+func TestContextWithBatchLinks(t *testing.T) {
+	exporter := setupTestTracing(t)
+	prevProp := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prevProp) })
+
+	// 三条消息分别来自不同的 trace
+	var carriers []propagation.TextMapCarrier
+	var want []trace.TraceID
+	for i := 0; i < 3; i++ {
+		ctx, producer := Tracer("producer").Start(context.Background(), fmt.Sprintf("produce-%d", i))
+		carrier := propagation.MapCarrier{}
+		otel.GetTextMapPropagator().Inject(ctx, carrier)
+		producer.End()
+		carriers = append(carriers, carrier)
+		want = append(want, producer.SpanContext().TraceID())
+	}
+	// 无效载体被跳过
+	carriers = append(carriers, propagation.MapCarrier{"traceparent": "garbage"}, nil)
+
+	_, span := ContextWithBatchLinks(context.Background(), "consume-batch", carriers)
+	span.End()
+
+	consumer, ok := findSpan(exporter.GetSpans(), "consume-batch")
+	if !ok {
+		t.Fatal("consume-batch span not exported")
+	}
+	if len(consumer.Links) != len(want) {
+		t.Fatalf("span has %d links, want %d", len(consumer.Links), len(want))
+	}
+	for i, link := range consumer.Links {
+		if link.SpanContext.TraceID() != want[i] {
+			t.Errorf("link %d trace ID = %s, want %s", i, link.SpanContext.TraceID(), want[i])
+		}
+	}
+	if v, _ := attrValue(consumer.Attributes, "messaging.batch.message_count"); v != "5" {
+		t.Errorf("messaging.batch.message_count = %q, want 5", v)
+	}
+}
+
+func TestSafeAddSpanEventConcurrentWithEnd(t *testing.T) {
+	exporter := setupTestTracing(t)
+	ctx, span := Tracer("test").Start(context.Background(), "parent")
+
+	const workers, perWorker = 8, 100
+	var (
+		wg       sync.WaitGroup
+		recorded sync.Map
+		start    = make(chan struct{})
+	)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for i := range perWorker {
+				if SafeAddSpanEvent(ctx, "tick") {
+					recorded.Store(fmt.Sprintf("%d-%d", w, i), struct{}{})
+				}
+			}
+		}()
+	}
+	close(start)
+	// 父 span 在事件添加过程中结束，之后添加的事件被丢弃
+	span.End()
+	wg.Wait()
+
+	if SafeAddSpanEvent(ctx, "late") {
+		t.Error("SafeAddSpanEvent after End returned true")
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("exported %d spans, want 1", len(spans))
+	}
+	var accepted int
+	recorded.Range(func(any, any) bool {
+		accepted++
+		return true
+	})
+	// 返回 true 的调用数不少于实际记录的事件数（IsRecording 与 AddEvent 之间结束的调用返回 true 但被丢弃）
+	if got := len(spans[0].Events); got > accepted {
+		t.Errorf("span has %d events, but only %d calls reported success", got, accepted)
+	}
+	for _, e := range spans[0].Events {
+		if e.Name != "tick" {
+			t.Errorf("unexpected event %q", e.Name)
+		}
+	}
+}
+
+// expensiveAttrs 模拟构造开销较大的属性
+func expensiveAttrs() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("payload", fmt.Sprintf("%064d", 42)),
+		attribute.Int("size", 64),
+	}
+}
+
+func TestSpanFuncHelpersSkipUnsampledSpans(t *testing.T) {
+	// 未采样的 span 不调用 fn，也不产生分配
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContext{})
+	called := false
+	AddSpanEventFunc(ctx, "event", func() []attribute.KeyValue { called = true; return nil })
+	SetSpanAttributesFunc(ctx, func() []attribute.KeyValue { called = true; return nil })
+	if called {
+		t.Error("attribute builder called for a non-recording span")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		AddSpanEventFunc(ctx, "event", expensiveAttrs)
+		SetSpanAttributesFunc(ctx, expensiveAttrs)
+	})
+	if allocs != 0 {
+		t.Errorf("non-recording span: %.1f allocs per call, want 0", allocs)
+	}
+}
+
+func BenchmarkAddSpanEventFunc(b *testing.B) {
+	b.Run("not recording", func(b *testing.B) {
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContext{})
+		b.ReportAllocs()
+		for b.Loop() {
+			AddSpanEventFunc(ctx, "event", expensiveAttrs)
+		}
+	})
+	b.Run("not recording eager", func(b *testing.B) {
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContext{})
+		b.ReportAllocs()
+		for b.Loop() {
+			AddSpanEvent(ctx, "event", expensiveAttrs()...)
+		}
+	})
+}
+
+func BenchmarkSetSpanAttributesFunc(b *testing.B) {
+	b.Run("not recording", func(b *testing.B) {
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContext{})
+		b.ReportAllocs()
+		for b.Loop() {
+			SetSpanAttributesFunc(ctx, expensiveAttrs)
+		}
+	})
+	b.Run("not recording eager", func(b *testing.B) {
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContext{})
+		b.ReportAllocs()
+		for b.Loop() {
+			SetSpanAttributes(ctx, expensiveAttrs()...)
+		}
+	})
+}
+
+func TestWithSpanValue(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	value, err := WithSpanValue(context.Background(), "compute", func(ctx context.Context) (int, error) {
+		if !trace.SpanFromContext(ctx).IsRecording() {
+			return 0, errors.New("fn did not run inside the span")
+		}
+		return 42, nil
+	})
+	if err != nil || value != 42 {
+		t.Errorf("WithSpanValue = %d, %v, want 42, nil", value, err)
+	}
+
+	errBoom := errors.New("boom")
+	value, err = WithSpanValue(context.Background(), "fail", func(context.Context) (int, error) {
+		return 7, errBoom
+	})
+	// 出错时仍原样返回 fn 的结果
+	if !errors.Is(err, errBoom) || value != 7 {
+		t.Errorf("WithSpanValue = %d, %v, want 7, boom", value, err)
+	}
+
+	spans := exporter.GetSpans()
+	succeeded, found := findSpan(spans, "compute")
+	if !found {
+		t.Fatal("compute span not exported")
+	}
+	if succeeded.Status.Code != codes.Unset {
+		t.Errorf("compute status = %v, want Unset", succeeded.Status.Code)
+	}
+	failed, found := findSpan(spans, "fail")
+	if !found {
+		t.Fatal("fail span not exported")
+	}
+	if failed.Status.Code != codes.Error || failed.Status.Description != "boom" {
+		t.Errorf("fail status = %+v, want Error with description boom", failed.Status)
+	}
+	if len(failed.Events) != 1 || failed.Events[0].Name != "exception" {
+		t.Errorf("fail events = %v, want one exception event", failed.Events)
+	}
+}
+
+func TestWithSpanDefaultsNested(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	ctx := WithSpanDefaults(context.Background(), attribute.String("tenant", "acme"))
+	ctx, parent := ContextWithSpan(ctx, "parent")
+	inner := WithSpanDefaults(ctx, attribute.String("stage", "load"))
+	err := WithSpan(inner, "child", func(ctx context.Context) error {
+		// 子 span 的上下文沿用默认属性，孙 span 同样带上
+		_, grandchild := ContextWithSpan(ctx, "grandchild", trace.WithAttributes(attribute.String("tenant", "override")))
+		grandchild.End()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 追加的默认属性只作用于派生的上下文
+	_, sibling := ContextWithSpan(ctx, "sibling")
+	sibling.End()
+	parent.End()
+
+	tests := []struct {
+		span      string
+		tenant    string
+		stage     string
+		wantStage bool
+	}{
+		{span: "parent", tenant: "acme"},
+		{span: "child", tenant: "acme", stage: "load", wantStage: true},
+		{span: "grandchild", tenant: "override", stage: "load", wantStage: true},
+		{span: "sibling", tenant: "acme"},
+	}
+	spans := exporter.GetSpans()
+	for _, tt := range tests {
+		span, ok := findSpan(spans, tt.span)
+		if !ok {
+			t.Fatalf("%s span not exported", tt.span)
+		}
+		if got, _ := attrValue(span.Attributes, "tenant"); got != tt.tenant {
+			t.Errorf("%s tenant = %q, want %q", tt.span, got, tt.tenant)
+		}
+		if got, ok := attrValue(span.Attributes, "stage"); ok != tt.wantStage || got != tt.stage {
+			t.Errorf("%s stage = %q (present %v), want %q (present %v)", tt.span, got, ok, tt.stage, tt.wantStage)
+		}
+	}
+}
+
+func TestEnsureTraceID(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	t.Run("existing span context", func(t *testing.T) {
+		ctx, span := Tracer("test").Start(context.Background(), "request")
+		defer span.End()
+		got, traceID := EnsureTraceID(ctx)
+		if got != ctx {
+			t.Error("EnsureTraceID replaced a context that already had a span")
+		}
+		if traceID != span.SpanContext().TraceID().String() {
+			t.Errorf("trace ID = %s, want %s", traceID, span.SpanContext().TraceID())
+		}
+	})
+
+	t.Run("new server root span", func(t *testing.T) {
+		exporter.Reset()
+		ctx, traceID := EnsureTraceID(context.Background())
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() || span.SpanContext().TraceID().String() != traceID {
+			t.Fatalf("context does not carry a recording span with trace ID %s", traceID)
+		}
+		span.End()
+
+		edge, ok := findSpan(exporter.GetSpans(), "edge")
+		if !ok {
+			t.Fatal("edge span not exported")
+		}
+		if edge.SpanKind != trace.SpanKindServer || edge.Parent.IsValid() {
+			t.Errorf("edge span kind = %s, parent valid = %v, want a server root span", edge.SpanKind, edge.Parent.IsValid())
+		}
+	})
+
+	t.Run("noop tracer", func(t *testing.T) {
+		prev := otel.GetTracerProvider()
+		otel.SetTracerProvider(tracenoop.NewTracerProvider())
+		defer otel.SetTracerProvider(prev)
+
+		ctx, traceID := EnsureTraceID(context.Background())
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() || !sc.IsRemote() || sc.TraceID().String() != traceID {
+			t.Errorf("span context = %+v, want a valid remote context with trace ID %s", sc, traceID)
+		}
+	})
+}
+
+func TestGoWithLimitAndSpanErrorMode(t *testing.T) {
+	errBoom := errors.New("boom")
+	// 并发为 1 时条目按顺序执行，第一个条目失败后再观察其余条目的上下文
+	run := func(opts ...GoOption) ([]error, error) {
+		var mu sync.Mutex
+		var siblingErrs []error
+		err := GoWithLimitAndSpan(context.Background(), "batch", 1, []int{0, 1, 2}, func(ctx context.Context, item int) error {
+			if item == 0 {
+				return errBoom
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			siblingErrs = append(siblingErrs, ctx.Err())
+			return nil
+		}, opts...)
+		return siblingErrs, err
+	}
+
+	t.Run("propagate", func(t *testing.T) {
+		setupTestTracing(t)
+		siblingErrs, err := run()
+		if !errors.Is(err, errBoom) {
+			t.Errorf("err = %v, want boom", err)
+		}
+		if len(siblingErrs) != 2 {
+			t.Fatalf("%d siblings ran, want 2", len(siblingErrs))
+		}
+		for i, siblingErr := range siblingErrs {
+			if !errors.Is(siblingErr, context.Canceled) {
+				t.Errorf("sibling %d ctx.Err() = %v, want context.Canceled", i, siblingErr)
+			}
+		}
+	})
+
+	t.Run("record only", func(t *testing.T) {
+		exporter := setupTestTracing(t)
+		siblingErrs, err := run(WithErrorMode(ErrorModeRecordOnly))
+		if err != nil {
+			t.Errorf("err = %v, want nil", err)
+		}
+		if len(siblingErrs) != 2 {
+			t.Fatalf("%d siblings ran, want 2", len(siblingErrs))
+		}
+		for i, siblingErr := range siblingErrs {
+			if siblingErr != nil {
+				t.Errorf("sibling %d ctx.Err() = %v, want nil", i, siblingErr)
+			}
+		}
+
+		spans := exporter.GetSpans()
+		parent, _ := findSpan(spans, "batch")
+		if v, _ := attrValue(parent.Attributes, "batch.error_count"); v != "1" {
+			t.Errorf("batch.error_count = %q, want 1", v)
+		}
+		if failed, _ := findSpan(spans, "batch-0"); failed.Status.Code != codes.Error {
+			t.Errorf("batch-0 status = %v, want Error", failed.Status.Code)
+		}
+	})
+}
+
+func TestGoDetached(t *testing.T) {
+	exporter := setupTestTracing(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, caller := Tracer("test").Start(ctx, "caller")
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	GoDetached(ctx, "detached", func(ctx context.Context) error {
+		<-release
+		done <- ctx.Err()
+		return nil
+	})
+	// 调用方结束 span 并取消上下文后，后台 goroutine 仍继续运行
+	caller.End()
+	cancel()
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("detached ctx.Err() = %v after caller cancellation, want nil", err)
+	}
+
+	var detached tracetest.SpanStub
+	waitFor(t, time.Second, func() bool {
+		var ok bool
+		detached, ok = findSpan(exporter.GetSpans(), "detached")
+		return ok
+	})
+	if detached.Parent.IsValid() {
+		t.Errorf("detached span has parent %s, want a new root", detached.Parent.SpanID())
+	}
+	if detached.SpanContext.TraceID() == caller.SpanContext().TraceID() {
+		t.Error("detached span shares the caller's trace")
+	}
+	if len(detached.Links) != 1 || !detached.Links[0].SpanContext.Equal(caller.SpanContext()) {
+		t.Errorf("detached links = %v, want one link to the caller span", detached.Links)
+	}
+}
+
+func TestFanIn(t *testing.T) {
+	t.Run("ordered results", func(t *testing.T) {
+		exporter := setupTestTracing(t)
+		// 后启动的 producer 先完成，结果仍按 producers 的顺序排列
+		producers := make([]func(context.Context) (int, error), 3)
+		for i := range producers {
+			producers[i] = func(context.Context) (int, error) {
+				time.Sleep(time.Duration(len(producers)-i) * 5 * time.Millisecond)
+				return i * 10, nil
+			}
+		}
+		results, err := FanIn(context.Background(), "fanin", producers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(results) != "[0 10 20]" {
+			t.Errorf("results = %v, want [0 10 20]", results)
+		}
+
+		// 父 span 在全部 producer span 结束后结束，并链接到每个 producer span
+		spans := exporter.GetSpans()
+		parent, ok := findSpan(spans, "fanin")
+		if !ok {
+			t.Fatal("fanin span not exported")
+		}
+		if len(parent.Links) != len(producers) {
+			t.Errorf("fanin span has %d links, want %d", len(parent.Links), len(producers))
+		}
+		for i := range producers {
+			child, ok := findSpan(spans, fmt.Sprintf("fanin-%d", i))
+			if !ok {
+				t.Fatalf("producer span fanin-%d not exported", i)
+			}
+			if child.Parent.SpanID() != parent.SpanContext.SpanID() {
+				t.Errorf("fanin-%d is not nested under the fanin span", i)
+			}
+			if child.EndTime.After(parent.EndTime) {
+				t.Errorf("fanin-%d ended after the fanin span", i)
+			}
+		}
+	})
+
+	t.Run("error cancels the other producers", func(t *testing.T) {
+		exporter := setupTestTracing(t)
+		errBoom := errors.New("boom")
+		producers := []func(context.Context) (string, error){
+			func(context.Context) (string, error) { return "ok", nil },
+			func(context.Context) (string, error) { return "", errBoom },
+			func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+		}
+		results, err := FanIn(context.Background(), "fanin", producers)
+		if !errors.Is(err, errBoom) {
+			t.Fatalf("err = %v, want boom", err)
+		}
+		if results[0] != "ok" || results[1] != "" || results[2] != "" {
+			t.Errorf("results = %q, want completed results with zero values for the rest", results)
+		}
+		parent, _ := findSpan(exporter.GetSpans(), "fanin")
+		if parent.Status.Code != codes.Error {
+			t.Errorf("fanin status = %v, want Error", parent.Status.Code)
+		}
+	})
+}
+
+func TestContextWithRemoteSpanContextRoundTrip(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	// 发送方：将 span 上下文序列化为 traceparent
+	_, sender := Tracer("test").Start(context.Background(), "send")
+	sender.End()
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(trace.ContextWithSpan(context.Background(), sender), carrier)
+
+	// 接收方：从自定义传输中还原 span 上下文并创建子 span
+	remote := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+	ctx := ContextWithRemoteSpanContext(context.Background(), remote)
+	_, receiver := ContextWithSpan(ctx, "receive")
+	receiver.End()
+
+	stub, ok := findSpan(exporter.GetSpans(), "receive")
+	if !ok {
+		t.Fatal("receive span not exported")
+	}
+	if stub.SpanContext.TraceID() != sender.SpanContext().TraceID() {
+		t.Errorf("trace ID = %s, want the sender's %s", stub.SpanContext.TraceID(), sender.SpanContext().TraceID())
+	}
+	if stub.Parent.SpanID() != sender.SpanContext().SpanID() || !stub.Parent.IsRemote() {
+		t.Errorf("parent = %s (remote %v), want the remote sender span %s", stub.Parent.SpanID(), stub.Parent.IsRemote(), sender.SpanContext().SpanID())
+	}
+
+	// 无效的 span 上下文原样返回 ctx
+	base := context.Background()
+	if got := ContextWithRemoteSpanContext(base, trace.SpanContext{}); got != base {
+		t.Error("ContextWithRemoteSpanContext with an invalid span context changed the context")
+	}
+}
+
+func TestContextSpanKinds(t *testing.T) {
+	exporter := setupTestTracing(t)
+
+	_, span := ContextWithSpan(context.Background(), "internal")
+	span.End()
+	_ = WithSpan(context.Background(), "with-span", func(context.Context) error { return nil })
+	_, span = ContextWithServerSpan(context.Background(), "consume")
+	span.End()
+	_, span = ContextWithClientSpan(context.Background(), "query")
+	span.End()
+	// 显式传入的 span 类型优先
+	_, span = ContextWithClientSpan(context.Background(), "publish", trace.WithSpanKind(trace.SpanKindProducer))
+	span.End()
+
+	want := map[string]trace.SpanKind{
+		"internal":  trace.SpanKindInternal,
+		"with-span": trace.SpanKindInternal,
+		"consume":   trace.SpanKindServer,
+		"query":     trace.SpanKindClient,
+		"publish":   trace.SpanKindProducer,
+	}
+	for name, kind := range want {
+		span, ok := findSpan(exporter.GetSpans(), name)
+		if !ok {
+			t.Fatalf("%s span not exported", name)
+		}
+		if span.SpanKind != kind {
+			t.Errorf("%s span kind = %s, want %s", name, span.SpanKind, kind)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// dependencyEdge 服务依赖图中的一条边
+type dependencyEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Calls  int64  `json:"calls"`
+	Errors int64  `json:"errors"`
+}
+
+// dependencyGraphProcessor 从带 peer.service 属性的客户端 span 中记录本服务到下游服务的调用边
+// 内存占用与不同下游的数量成正比，仅用于本地/开发环境快速查看服务依赖，不能替代后端的服务拓扑
+type dependencyGraphProcessor struct {
+	mu    sync.Mutex
+	edges map[[2]string]*dependencyEdge
+}
+
+func newDependencyGraphProcessor() *dependencyGraphProcessor {
+	return &dependencyGraphProcessor{edges: make(map[[2]string]*dependencyEdge)}
+}
+
+func (p *dependencyGraphProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+// OnEnd 记录客户端/生产者 span 的调用边
+func (p *dependencyGraphProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if kind := s.SpanKind(); kind != trace.SpanKindClient && kind != trace.SpanKindProducer {
+		return
+	}
+
+	var peer string
+	for _, kv := range s.Attributes() {
+		if kv.Key == semconv.PeerServiceKey {
+			peer = kv.Value.AsString()
+			break
+		}
+	}
+	if peer == "" {
+		return
+	}
+
+	from := "unknown"
+	if value, ok := s.Resource().Set().Value(semconv.ServiceNameKey); ok {
+		from = value.AsString()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := [2]string{from, peer}
+	edge, ok := p.edges[key]
+	if !ok {
+		edge = &dependencyEdge{From: from, To: peer}
+		p.edges[key] = edge
+	}
+	edge.Calls++
+	if s.Status().Code == codes.Error {
+		edge.Errors++
+	}
+}
+
+func (p *dependencyGraphProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *dependencyGraphProcessor) ForceFlush(context.Context) error { return nil }
+
+// snapshot 按 from、to 排序返回所有边的副本
+func (p *dependencyGraphProcessor) snapshot() []dependencyEdge {
+	p.mu.Lock()
+	edges := make([]dependencyEdge, 0, len(p.edges))
+	for _, edge := range p.edges {
+		edges = append(edges, *edge)
+	}
+	p.mu.Unlock()
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// DependencyGraphHandler 以 JSON 输出观测到的服务依赖边（含调用次数与错误次数），未启用 EnableDependencyGraph 时返回 404
+func (tp *TraceProvider) DependencyGraphHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if tp == nil || tp.dependencyGraph == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			Edges []dependencyEdge `json:"edges"`
+		}{Edges: tp.dependencyGraph.snapshot()})
+	}
+}
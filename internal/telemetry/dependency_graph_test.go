@@ -0,0 +1,79 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// readDependencyGraph 调用 handler 并解析返回的依赖边
+func readDependencyGraph(t *testing.T, h http.Handler) (int, []dependencyEdge) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/dependencies", nil))
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var body struct {
+		Edges []dependencyEdge `json:"edges"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode dependency graph: %v", err)
+	}
+	return rec.Code, body.Edges
+}
+
+func TestDependencyGraph(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.ServiceName = "checkout"
+	cfg.EnableDependencyGraph = true
+	p := newTestProvider(t, cfg)
+	tracer := p.Tracer("test")
+
+	record := func(name string, kind trace.SpanKind, peer string, failed bool) {
+		opts := []trace.SpanStartOption{trace.WithSpanKind(kind)}
+		if peer != "" {
+			opts = append(opts, trace.WithAttributes(semconv.PeerService(peer)))
+		}
+		_, span := tracer.Start(context.Background(), name, opts...)
+		if failed {
+			span.SetStatus(codes.Error, "unavailable")
+		}
+		span.End()
+	}
+	record("charge", trace.SpanKindClient, "payments", false)
+	record("charge", trace.SpanKindClient, "payments", true)
+	record("reserve", trace.SpanKindClient, "inventory", false)
+	record("publish", trace.SpanKindProducer, "events", false)
+	// 服务端、内部 span 以及缺少 peer.service 的客户端 span 不记录
+	record("handle", trace.SpanKindServer, "gateway", false)
+	record("compute", trace.SpanKindInternal, "cache", false)
+	record("call", trace.SpanKindClient, "", false)
+
+	code, edges := readDependencyGraph(t, p.DependencyGraphHandler())
+	if code != http.StatusOK {
+		t.Fatalf("DependencyGraphHandler status = %d, want 200", code)
+	}
+	want := []dependencyEdge{
+		{From: "checkout", To: "events", Calls: 1},
+		{From: "checkout", To: "inventory", Calls: 1},
+		{From: "checkout", To: "payments", Calls: 2, Errors: 1},
+	}
+	if !reflect.DeepEqual(edges, want) {
+		t.Errorf("dependency graph = %+v, want %+v", edges, want)
+	}
+}
+
+func TestDependencyGraphDisabled(t *testing.T) {
+	p := newTestProvider(t, testProviderConfig())
+	if code, _ := readDependencyGraph(t, p.DependencyGraphHandler()); code != http.StatusNotFound {
+		t.Errorf("DependencyGraphHandler status = %d, want 404 when disabled", code)
+	}
+}
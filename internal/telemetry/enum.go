@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// enumValues 通过 RegisterEnumValues 登记的属性键及其允许的取值
+var enumValues sync.Map // map[string]map[string]struct{}
+
+// enumWarned 已输出过警告的 键=值，每个非法取值只警告一次
+var enumWarned sync.Map
+
+// RegisterEnumValues 登记属性键允许的取值，之后 EnumAttr 遇到未登记的取值时输出警告
+// 通常在定义枚举类型的包的 init 中调用；重复登记同一个键会覆盖之前的取值
+func RegisterEnumValues[T ~string](key string, values ...T) {
+	allowed := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		allowed[string(v)] = struct{}{}
+	}
+	enumValues.Store(key, allowed)
+}
+
+// EnumAttr 以具名字符串类型构造属性，避免在调用处手写字符串导致的拼写错误
+// 日志级别为 debug（如 development 环境）时，对已登记键的未登记取值输出一次警告；其他级别下不做检查
+func EnumAttr[T ~string](key string, value T) attribute.KeyValue {
+	if zap.L().Core().Enabled(zapcore.DebugLevel) {
+		checkEnumValue(key, string(value))
+	}
+	return attribute.String(key, string(value))
+}
+
+// checkEnumValue 检查取值是否已为该键登记，未登记键不做检查
+func checkEnumValue(key, value string) {
+	allowed, ok := enumValues.Load(key)
+	if !ok {
+		return
+	}
+	if _, ok := allowed.(map[string]struct{})[value]; ok {
+		return
+	}
+	if _, warned := enumWarned.LoadOrStore(key+"="+value, struct{}{}); warned {
+		return
+	}
+	zap.L().Warn("Unexpected enum attribute value",
+		zap.String("key", key),
+		zap.String("value", value),
+	)
+}
@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testColor string
+
+const (
+	testColorRed  testColor = "red"
+	testColorBlue testColor = "blue"
+)
+
+func TestEnumAttrWarnsOnUnknownValues(t *testing.T) {
+	RegisterEnumValues("test.color", testColorRed, testColorBlue)
+	t.Cleanup(func() {
+		enumValues.Delete("test.color")
+		enumWarned.Delete("test.color=green")
+	})
+
+	core, logs := observer.New(zap.DebugLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+
+	if attr := EnumAttr("test.color", testColorRed); attr.Value.AsString() != "red" {
+		t.Errorf("EnumAttr value = %q, want red", attr.Value.AsString())
+	}
+	if logs.Len() != 0 {
+		t.Errorf("registered value logged %d warnings, want 0", logs.Len())
+	}
+
+	// 未登记的取值仍原样返回，只警告一次
+	if attr := EnumAttr("test.color", testColor("green")); attr.Value.AsString() != "green" {
+		t.Errorf("EnumAttr value = %q, want green", attr.Value.AsString())
+	}
+	EnumAttr("test.color", testColor("green"))
+	warnings := logs.FilterMessage("Unexpected enum attribute value").All()
+	if len(warnings) != 1 {
+		t.Fatalf("logged %d warnings for an unknown value, want 1", len(warnings))
+	}
+	if fields := warnings[0].ContextMap(); fields["key"] != "test.color" || fields["value"] != "green" {
+		t.Errorf("warning fields = %v, want key test.color and value green", fields)
+	}
+
+	// 未登记的键不做检查
+	EnumAttr("test.shape", "hexagon")
+	if logs.Len() != 1 {
+		t.Errorf("unregistered key logged %d warnings in total, want 1", logs.Len())
+	}
+}
+
+func TestEnumAttrSkipsCheckAboveDebug(t *testing.T) {
+	RegisterEnumValues("test.size", "small", "large")
+	t.Cleanup(func() {
+		enumValues.Delete("test.size")
+		enumWarned.Delete("test.size=huge")
+	})
+
+	core, logs := observer.New(zap.InfoLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+
+	EnumAttr("test.size", "huge")
+	if logs.Len() != 0 {
+		t.Errorf("logged %d warnings at info level, want 0", logs.Len())
+	}
+}
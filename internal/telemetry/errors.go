@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorClassifier 将错误归类为 kind 并判断是否可重试
+type ErrorClassifier func(err error) (kind string, retryable bool)
+
+// errorClassifier 当前生效的错误分类器
+var errorClassifier atomic.Value
+
+func init() {
+	errorClassifier.Store(ErrorClassifier(DefaultErrorClassifier))
+}
+
+// SetErrorClassifier 设置 WithSpan 使用的错误分类器，传入 nil 时恢复默认分类器
+func SetErrorClassifier(classifier ErrorClassifier) {
+	if classifier == nil {
+		classifier = DefaultErrorClassifier
+	}
+	errorClassifier.Store(classifier)
+}
+
+// DefaultErrorClassifier 默认错误分类器，识别上下文超时/取消与 gRPC status 错误
+func DefaultErrorClassifier(err error) (string, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded", true
+	case errors.Is(err, context.Canceled):
+		return "canceled", false
+	}
+
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case grpccodes.Unavailable, grpccodes.ResourceExhausted, grpccodes.Aborted, grpccodes.DeadlineExceeded:
+			return "grpc." + st.Code().String(), true
+		default:
+			return "grpc." + st.Code().String(), false
+		}
+	}
+
+	// 其他错误以错误链最内层的类型作为 kind
+	inner := err
+	for next := errors.Unwrap(inner); next != nil; next = errors.Unwrap(inner) {
+		inner = next
+	}
+	return fmt.Sprintf("%T", inner), false
+}
+
+// recordSpanError 记录错误、设置错误状态并附加分类属性
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+
+	classify := errorClassifier.Load().(ErrorClassifier)
+	kind, retryable := classify(err)
+	span.SetAttributes(
+		attribute.String("error.kind", kind),
+		attribute.Bool("error.retryable", retryable),
+	)
+}
+
+// RecordNonFatalError 将可恢复的错误记录为 span 事件（附加 fatal=false），但不修改 span 状态
+// 与 WithSpan 返回错误时的行为不同：后者会将 span 状态设为 Error 并附加 error.kind/error.retryable，
+// 而这里记录的错误仅用于诊断，操作本身仍视为成功（如重试后成功、降级到缓存等）
+func RecordNonFatalError(ctx context.Context, err error, attrs ...attribute.KeyValue) {
+	if err == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		attrs = append(attrs, attribute.Bool("fatal", false))
+		span.RecordError(err, trace.WithAttributes(attrs...))
+	}
+}
+
+// TracedError 携带产生错误时所在 span 的上下文，span 结束后仍可通过 TraceIDFromError 取得 trace ID
+type TracedError struct {
+	err         error
+	spanContext trace.SpanContext
+}
+
+// Error 返回被包装错误的信息
+func (e *TracedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 返回被包装的错误，支持 errors.Is/errors.As
+func (e *TracedError) Unwrap() error {
+	return e.err
+}
+
+// SpanContext 返回产生错误时所在 span 的上下文
+func (e *TracedError) SpanContext() trace.SpanContext {
+	return e.spanContext
+}
+
+// WrapError 使用 ctx 中当前 span 的 trace/span ID 包装错误，便于顶层错误处理在 span 结束后记录来源 trace
+// err 为 nil、ctx 中没有有效 span 或错误链中已带有 span 上下文时原样返回（保留最内层的来源）
+func WrapError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return err
+	}
+	var traced *TracedError
+	if errors.As(err, &traced) {
+		return err
+	}
+	return &TracedError{err: err, spanContext: sc}
+}
+
+// TraceIDFromError 返回错误链中由 WrapError 记录的 trace ID
+func TraceIDFromError(err error) (string, bool) {
+	var traced *TracedError
+	if !errors.As(err, &traced) {
+		return "", false
+	}
+	return traced.spanContext.TraceID().String(), true
+}
@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWrapError(t *testing.T) {
+	setupTestTracing(t)
+	ctx, inner := Tracer("errors-test").Start(context.Background(), "inner")
+	wrapped := WrapError(ctx, io.ErrUnexpectedEOF)
+	inner.End()
+
+	// 跨层包装后，span 结束也仍能取得来源 trace ID
+	err := fmt.Errorf("load config: %w", wrapped)
+	traceID, ok := TraceIDFromError(err)
+	if !ok || traceID != inner.SpanContext().TraceID().String() {
+		t.Errorf("TraceIDFromError = %q, %v, want %s", traceID, ok, inner.SpanContext().TraceID())
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Error("errors.Is does not see the wrapped error")
+	}
+	if err.Error() != "load config: unexpected EOF" {
+		t.Errorf("Error() = %q, want the original message", err.Error())
+	}
+	var traced *TracedError
+	if !errors.As(err, &traced) || traced.SpanContext().SpanID() != inner.SpanContext().SpanID() {
+		t.Error("errors.As does not return the originating span context")
+	}
+
+	// 外层再次包装时保留最内层的来源
+	outerCtx, outer := Tracer("errors-test").Start(context.Background(), "outer")
+	defer outer.End()
+	if got := WrapError(outerCtx, err); got != err {
+		t.Errorf("rewrapping returned %v, want the error unchanged", got)
+	}
+}
+
+func TestWrapErrorWithoutSpan(t *testing.T) {
+	if WrapError(context.Background(), nil) != nil {
+		t.Error("WrapError(nil) != nil")
+	}
+	err := WrapError(context.Background(), io.EOF)
+	if err != io.EOF {
+		t.Errorf("WrapError without a span = %v, want the error unchanged", err)
+	}
+	if _, ok := TraceIDFromError(err); ok {
+		t.Error("TraceIDFromError found a trace ID on an unwrapped error")
+	}
+}
+
+func TestRecordNonFatalError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	setupTestTracing(t, sdktrace.WithSpanProcessor(recorder))
+
+	ctx, span := Tracer("errors-test").Start(context.Background(), "op")
+	RecordNonFatalError(ctx, io.ErrUnexpectedEOF, attribute.String("fallback", "cache"))
+	RecordNonFatalError(ctx, nil)
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(ended))
+	}
+	if status := ended[0].Status(); status.Code != codes.Unset {
+		t.Errorf("status = %v, want Unset", status.Code)
+	}
+	events := ended[0].Events()
+	if len(events) != 1 || events[0].Name != "exception" {
+		t.Fatalf("events = %v, want a single exception event", events)
+	}
+	attrs := attribute.NewSet(events[0].Attributes...)
+	if v, ok := attrs.Value("fatal"); !ok || v.AsBool() {
+		t.Errorf("fatal = %v, want false", v)
+	}
+	if v, _ := attrs.Value("fallback"); v.AsString() != "cache" {
+		t.Errorf("fallback = %q, want cache", v.AsString())
+	}
+	if v, _ := attrs.Value("exception.message"); v.AsString() != io.ErrUnexpectedEOF.Error() {
+		t.Errorf("exception.message = %q, want %q", v.AsString(), io.ErrUnexpectedEOF)
+	}
+}
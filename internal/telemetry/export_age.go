@@ -0,0 +1,142 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// exportAgeTracker 记录进入批处理队列的 span 的入队时间，导出后清除，
+// 通过 telemetry_oldest_unexported_span_age_seconds 暴露最早未导出 span 的等待时长
+// 最多跟踪 sdktrace.DefaultMaxQueueSize 个 span（与批处理队列容量一致），超出的 span 不再跟踪
+type exportAgeTracker struct {
+	mu       sync.Mutex
+	enqueued map[trace.SpanID]time.Time
+	max      int
+	reg      metric.Registration
+}
+
+// newExportAgeTracker 在给定 meter 上注册等待时长指标，追踪结束后需调用 close 注销
+func newExportAgeTracker(meter metric.Meter) (*exportAgeTracker, error) {
+	t := &exportAgeTracker{
+		enqueued: make(map[trace.SpanID]time.Time),
+		max:      sdktrace.DefaultMaxQueueSize,
+	}
+
+	age, err := meter.Float64ObservableGauge("telemetry_oldest_unexported_span_age_seconds",
+		metric.WithDescription("Age of the oldest span waiting to be exported; a rising value indicates an export stall"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create unexported span age gauge: %w", err)
+	}
+	t.reg, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveFloat64(age, t.oldestAge().Seconds())
+		return nil
+	}, age)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register unexported span age callback: %w", err)
+	}
+	return t, nil
+}
+
+// close 注销等待时长指标的回调
+func (t *exportAgeTracker) close() error {
+	return t.reg.Unregister()
+}
+
+// enqueue 记录 span 的入队时间
+func (t *exportAgeTracker) enqueue(id trace.SpanID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.enqueued) < t.max {
+		t.enqueued[id] = time.Now()
+	}
+}
+
+// exported 清除已导出的 span；批处理器按入队顺序导出，
+// 因此早于本批最晚入队时间且仍未导出的 span 已被队列丢弃，一并清除
+func (t *exportAgeTracker) exported(spans []sdktrace.ReadOnlySpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var latest time.Time
+	for _, s := range spans {
+		id := s.SpanContext().SpanID()
+		if enqueuedAt, ok := t.enqueued[id]; ok {
+			if enqueuedAt.After(latest) {
+				latest = enqueuedAt
+			}
+			delete(t.enqueued, id)
+		}
+	}
+	for id, enqueuedAt := range t.enqueued {
+		if !enqueuedAt.After(latest) {
+			delete(t.enqueued, id)
+		}
+	}
+}
+
+// oldestAge 返回最早未导出 span 的等待时长，没有未导出的 span 时为 0
+func (t *exportAgeTracker) oldestAge() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var oldest time.Time
+	for _, enqueuedAt := range t.enqueued {
+		if oldest.IsZero() || enqueuedAt.Before(oldest) {
+			oldest = enqueuedAt
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return time.Since(oldest)
+}
+
+// exportAgeSpanProcessor 在 span 交给批处理器时记录入队时间
+type exportAgeSpanProcessor struct {
+	tracker *exportAgeTracker
+	next    sdktrace.SpanProcessor
+}
+
+func (p *exportAgeSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *exportAgeSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.SpanContext().IsSampled() {
+		p.tracker.enqueue(s.SpanContext().SpanID())
+	}
+	p.next.OnEnd(s)
+}
+
+// Shutdown 关闭下游处理器并注销等待时长指标
+func (p *exportAgeSpanProcessor) Shutdown(ctx context.Context) error {
+	err := p.next.Shutdown(ctx)
+	if closeErr := p.tracker.close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (p *exportAgeSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// exportAgeExporter 在导出完成（无论成功与否）后清除对应 span 的入队记录
+type exportAgeExporter struct {
+	sdktrace.SpanExporter
+	tracker *exportAgeTracker
+}
+
+func (e *exportAgeExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.tracker.exported(spans)
+	return err
+}
@@ -0,0 +1,74 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// blockingExporter 在 release 关闭前阻塞导出，模拟导出停滞
+type blockingExporter struct {
+	*tracetest.InMemoryExporter
+	release chan struct{}
+}
+
+func (e *blockingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	<-e.release
+	return e.InMemoryExporter.ExportSpans(ctx, spans)
+}
+
+// oldestUnexportedAge 返回 telemetry_oldest_unexported_span_age_seconds 的当前值
+func oldestUnexportedAge(t *testing.T, reader sdkmetric.Reader) float64 {
+	t.Helper()
+	_, m, ok := collectMetric(t, reader, "telemetry_oldest_unexported_span_age_seconds")
+	if !ok {
+		t.Fatal("telemetry_oldest_unexported_span_age_seconds not collected")
+	}
+	return m.Data.(metricdata.Gauge[float64]).DataPoints[0].Value
+}
+
+func TestExportAgeRisesWhileExportStalls(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer mp.Shutdown(context.Background())
+
+	tracker, err := newExportAgeTracker(mp.Meter(internalScopeName))
+	if err != nil {
+		t.Fatalf("newExportAgeTracker: %v", err)
+	}
+	exporter := &blockingExporter{InMemoryExporter: tracetest.NewInMemoryExporter(), release: make(chan struct{})}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(&exportAgeSpanProcessor{
+		tracker: tracker,
+		next: sdktrace.NewBatchSpanProcessor(&exportAgeExporter{SpanExporter: exporter, tracker: tracker},
+			sdktrace.WithBatchTimeout(time.Millisecond)),
+	}))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "stalled")
+	span.End()
+
+	first := oldestUnexportedAge(t, reader)
+	time.Sleep(20 * time.Millisecond)
+	second := oldestUnexportedAge(t, reader)
+	if first <= 0 || second <= first {
+		t.Fatalf("age did not rise while the export was stalled: %v then %v", first, second)
+	}
+
+	close(exporter.release)
+	if err := tp.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("ForceFlush: %v", err)
+	}
+	if got := oldestUnexportedAge(t, reader); got != 0 {
+		t.Errorf("age after export = %v, want 0", got)
+	}
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if _, _, ok := collectMetric(t, reader, "telemetry_oldest_unexported_span_age_seconds"); ok {
+		t.Error("age gauge still reported after Shutdown")
+	}
+}
@@ -2,28 +2,123 @@ package telemetry
 
 import (
 	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // GRPCMiddleware 提供 gRPC 服务端和客户端的自动插桩
 type GRPCMiddleware struct {
-	tracer trace.Tracer
+	tracer           trace.Tracer
+	propagationDebug bool
+	unsetCodes       map[grpccodes.Code]bool
+	captureMetadata  []string
+	peerService      string
+	perMessageSpans  bool
+}
+
+// sensitiveMetadataKeys 敏感元数据键，通过 WithCaptureMetadata 显式列出时仍会采集，但输出一次警告
+var sensitiveMetadataKeys = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+}
+
+// sensitiveMetadataWarned 已输出过采集警告的敏感元数据键
+var sensitiveMetadataWarned sync.Map
+
+// defaultUnsetStatusCodes 按规范视为客户端错误的 gRPC 状态码，服务端 span 状态保持 Unset
+var defaultUnsetStatusCodes = []grpccodes.Code{
+	grpccodes.Canceled,
+	grpccodes.InvalidArgument,
+	grpccodes.NotFound,
+	grpccodes.AlreadyExists,
+	grpccodes.PermissionDenied,
+	grpccodes.ResourceExhausted,
+	grpccodes.FailedPrecondition,
+	grpccodes.Aborted,
+	grpccodes.OutOfRange,
+	grpccodes.Unauthenticated,
+}
+
+// GRPCOption 配置 gRPC 中间件的选项
+type GRPCOption func(*GRPCMiddleware)
+
+// WithPropagationDebug 启用传播调试：注入/提取上下文后以 debug 级别记录传播的元数据
+func WithPropagationDebug() GRPCOption {
+	return func(g *GRPCMiddleware) {
+		g.propagationDebug = true
+	}
+}
+
+// WithUnsetStatusCodes 设置不将服务端 span 标记为错误的 gRPC 状态码（替换默认的客户端错误集合）
+func WithUnsetStatusCodes(statusCodes ...grpccodes.Code) GRPCOption {
+	return func(g *GRPCMiddleware) {
+		g.unsetCodes = make(map[grpccodes.Code]bool, len(statusCodes))
+		for _, c := range statusCodes {
+			g.unsetCodes[c] = true
+		}
+	}
+}
+
+// WithCaptureMetadata 将白名单中的入站元数据记录为 rpc.grpc.request.metadata.<key> 属性
+// 多值元数据以逗号拼接；未列出的键不采集，列出敏感键（如 authorization）时照常采集并输出一次警告
+func WithCaptureMetadata(keys ...string) GRPCOption {
+	return func(g *GRPCMiddleware) {
+		for _, key := range keys {
+			key = strings.ToLower(key)
+			if sensitiveMetadataKeys[key] {
+				if _, warned := sensitiveMetadataWarned.LoadOrStore(key, struct{}{}); !warned {
+					zap.L().Warn("Capturing sensitive gRPC metadata as span attribute", zap.String("key", key))
+				}
+			}
+			g.captureMetadata = append(g.captureMetadata, key)
+		}
+	}
+}
+
+// WithPeerService 为客户端 span 设置 peer.service 属性（通常为目标服务名），
+// Jaeger 等后端据此构建服务依赖拓扑
+func WithPeerService(name string) GRPCOption {
+	return func(g *GRPCMiddleware) {
+		g.peerService = name
+	}
+}
+
+// WithPerMessageSpans 使 WrapStreamHandler 为流中的每条消息创建 stream.recv/stream.send 子 span，
+// 带有 rpc.message.id（同方向从 1 开始的序号）属性，便于观察长连接流中单条消息的耗时；
+// 每条消息都会产生一个 span，高吞吐的流慎用
+func WithPerMessageSpans() GRPCOption {
+	return func(g *GRPCMiddleware) {
+		g.perMessageSpans = true
+	}
 }
 
 // NewGRPCMiddleware 创建 gRPC 中间件
-func NewGRPCMiddleware(serviceName string) *GRPCMiddleware {
-	return &GRPCMiddleware{
-		tracer: otel.Tracer(serviceName),
+func NewGRPCMiddleware(serviceName string, opts ...GRPCOption) *GRPCMiddleware {
+	g := &GRPCMiddleware{
+		tracer: Tracer(serviceName),
+	}
+	WithUnsetStatusCodes(defaultUnsetStatusCodes...)(g)
+	for _, opt := range opts {
+		opt(g)
 	}
+	return g
 }
 
 // UnaryServerInterceptor 返回 gRPC 服务端一元调用拦截器
@@ -44,18 +139,24 @@ func (g *GRPCMiddleware) StreamServerInterceptor() grpc.StreamServerInterceptor
 
 // UnaryClientInterceptor 返回 gRPC 客户端一元调用拦截器
 func (g *GRPCMiddleware) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
-	return otelgrpc.UnaryClientInterceptor(
-		otelgrpc.WithTracerProvider(otel.GetTracerProvider()),
-		otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
-	)
+	return otelgrpc.UnaryClientInterceptor(g.clientOptions()...)
 }
 
 // StreamClientInterceptor 返回 gRPC 客户端流式调用拦截器
 func (g *GRPCMiddleware) StreamClientInterceptor() grpc.StreamClientInterceptor {
-	return otelgrpc.StreamClientInterceptor(
+	return otelgrpc.StreamClientInterceptor(g.clientOptions()...)
+}
+
+// clientOptions 返回客户端拦截器使用的 otelgrpc 配置
+func (g *GRPCMiddleware) clientOptions() []otelgrpc.Option {
+	opts := []otelgrpc.Option{
 		otelgrpc.WithTracerProvider(otel.GetTracerProvider()),
 		otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
-	)
+	}
+	if g.peerService != "" {
+		opts = append(opts, otelgrpc.WithSpanOptions(trace.WithAttributes(semconv.PeerService(g.peerService))))
+	}
+	return opts
 }
 
 // DialOption 返回配置了追踪的 gRPC 客户端连接选项
@@ -74,7 +175,7 @@ func (g *GRPCMiddleware) ServerOptions() []grpc.ServerOption {
 // WrapUnaryHandler 包装一元 gRPC 处理器，添加自定义属性
 func (g *GRPCMiddleware) WrapUnaryHandler(operationName string, handler grpc.UnaryHandler) grpc.UnaryHandler {
 	return func(ctx context.Context, req interface{}) (interface{}, error) {
-		ctx, span := g.tracer.Start(ctx, operationName)
+		ctx, span := g.tracer.Start(ctx, operationName, trace.WithSpanKind(trace.SpanKindServer))
 		defer span.End()
 
 		// 添加请求属性
@@ -88,6 +189,7 @@ func (g *GRPCMiddleware) WrapUnaryHandler(operationName string, handler grpc.Una
 			if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
 				span.SetAttributes(attribute.String("rpc.user_agent", userAgent[0]))
 			}
+			span.SetAttributes(g.metadataAttributes(md)...)
 		}
 
 		start := time.Now()
@@ -96,22 +198,7 @@ func (g *GRPCMiddleware) WrapUnaryHandler(operationName string, handler grpc.Una
 
 		// 设置响应属性
 		span.SetAttributes(attribute.Int64("rpc.duration_ms", duration.Milliseconds()))
-
-		if err != nil {
-			// 设置错误状态
-			if st, ok := status.FromError(err); ok {
-				span.SetAttributes(
-					attribute.String("rpc.grpc.status_code", st.Code().String()),
-					attribute.Int("rpc.grpc.status_code_int", int(st.Code())),
-				)
-				span.SetStatus(codes.Error, st.Message())
-			} else {
-				span.SetStatus(codes.Error, err.Error())
-			}
-		} else {
-			span.SetAttributes(attribute.String("rpc.grpc.status_code", "OK"))
-			span.SetStatus(codes.Ok, "")
-		}
+		g.setSpanStatus(span, err)
 
 		return resp, err
 	}
@@ -120,7 +207,7 @@ func (g *GRPCMiddleware) WrapUnaryHandler(operationName string, handler grpc.Una
 // WrapStreamHandler 包装流式 gRPC 处理器
 func (g *GRPCMiddleware) WrapStreamHandler(operationName string, handler grpc.StreamHandler) grpc.StreamHandler {
 	return func(srv interface{}, stream grpc.ServerStream) error {
-		ctx, span := g.tracer.Start(stream.Context(), operationName)
+		ctx, span := g.tracer.Start(stream.Context(), operationName, trace.WithSpanKind(trace.SpanKindServer))
 		defer span.End()
 
 		// 添加请求属性
@@ -135,6 +222,11 @@ func (g *GRPCMiddleware) WrapStreamHandler(operationName string, handler grpc.St
 			if userAgent := md.Get("user-agent"); len(userAgent) > 0 {
 				span.SetAttributes(attribute.String("rpc.user_agent", userAgent[0]))
 			}
+			span.SetAttributes(g.metadataAttributes(md)...)
+		}
+
+		if g.perMessageSpans {
+			stream = &tracedServerStream{ServerStream: stream, ctx: ctx, tracer: g.tracer}
 		}
 
 		start := time.Now()
@@ -143,23 +235,88 @@ func (g *GRPCMiddleware) WrapStreamHandler(operationName string, handler grpc.St
 
 		// 设置响应属性
 		span.SetAttributes(attribute.Int64("rpc.duration_ms", duration.Milliseconds()))
+		g.setSpanStatus(span, err)
 
-		if err != nil {
-			if st, ok := status.FromError(err); ok {
-				span.SetAttributes(
-					attribute.String("rpc.grpc.status_code", st.Code().String()),
-					attribute.Int("rpc.grpc.status_code_int", int(st.Code())),
-				)
-				span.SetStatus(codes.Error, st.Message())
-			} else {
-				span.SetStatus(codes.Error, err.Error())
-			}
-		} else {
-			span.SetAttributes(attribute.String("rpc.grpc.status_code", "OK"))
-			span.SetStatus(codes.Ok, "")
+		return err
+	}
+}
+
+// tracedServerStream 为每次 RecvMsg/SendMsg 创建流 span 的子 span，Context 返回带有流 span 的上下文
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	tracer trace.Tracer
+	recv   atomic.Int64
+	sent   atomic.Int64
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg 在 stream.recv 子 span 中接收消息，流正常结束（io.EOF）不视为错误
+func (s *tracedServerStream) RecvMsg(m any) error {
+	_, span := s.tracer.Start(s.ctx, "stream.recv", trace.WithAttributes(
+		attribute.String("rpc.message.type", "RECEIVED"),
+		attribute.Int64("rpc.message.id", s.recv.Add(1)),
+	))
+	defer span.End()
+
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil && !errors.Is(err, io.EOF) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// SendMsg 在 stream.send 子 span 中发送消息
+func (s *tracedServerStream) SendMsg(m any) error {
+	_, span := s.tracer.Start(s.ctx, "stream.send", trace.WithAttributes(
+		attribute.String("rpc.message.type", "SENT"),
+		attribute.Int64("rpc.message.id", s.sent.Add(1)),
+	))
+	defer span.End()
+
+	err := s.ServerStream.SendMsg(m)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// metadataAttributes 将白名单中的元数据转换为 span 属性
+func (g *GRPCMiddleware) metadataAttributes(md metadata.MD) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(g.captureMetadata))
+	for _, key := range g.captureMetadata {
+		if values := md.Get(key); len(values) > 0 {
+			attrs = append(attrs, attribute.String("rpc.grpc.request.metadata."+key, strings.Join(values, ",")))
 		}
+	}
+	return attrs
+}
 
-		return err
+// setSpanStatus 根据处理结果设置 span 状态，客户端错误类状态码保持 Unset
+func (g *GRPCMiddleware) setSpanStatus(span trace.Span, err error) {
+	if err == nil {
+		span.SetAttributes(attribute.String("rpc.grpc.status_code", "OK"))
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("rpc.grpc.status_code", st.Code().String()),
+		attribute.Int("rpc.grpc.status_code_int", int(st.Code())),
+	)
+	if !g.unsetCodes[st.Code()] {
+		span.SetStatus(codes.Error, st.Message())
 	}
 }
 
@@ -168,17 +325,42 @@ func (g *GRPCMiddleware) PropagateContext(ctx context.Context) context.Context {
 	// 创建元数据并注入上下文
 	md := metadata.New(nil)
 	otel.GetTextMapPropagator().Inject(ctx, &metadataCarrier{md})
+
+	if g.propagationDebug {
+		LoggerWithContext(ctx).Debug("Injected gRPC propagation metadata", propagationFields(md)...)
+	}
+
 	return metadata.NewOutgoingContext(ctx, md)
 }
 
 // ExtractContext 从 gRPC 上下文提取追踪上下文
 func (g *GRPCMiddleware) ExtractContext(ctx context.Context) context.Context {
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		return otel.GetTextMapPropagator().Extract(ctx, &metadataCarrier{md})
+		extracted := otel.GetTextMapPropagator().Extract(ctx, &metadataCarrier{md})
+
+		if g.propagationDebug {
+			LoggerWithContext(extracted).Debug("Extracted gRPC propagation metadata", propagationFields(md)...)
+		}
+
+		return extracted
+	}
+
+	if g.propagationDebug {
+		LoggerWithContext(ctx).Debug("No incoming gRPC metadata to extract")
 	}
 	return ctx
 }
 
+// propagationFields 将传播器关注的元数据键值转换为日志字段
+func propagationFields(md metadata.MD) []zap.Field {
+	carrier := &metadataCarrier{md}
+	fields := make([]zap.Field, 0, len(otel.GetTextMapPropagator().Fields()))
+	for _, key := range otel.GetTextMapPropagator().Fields() {
+		fields = append(fields, zap.String("metadata."+key, carrier.Get(key)))
+	}
+	return fields
+}
+
 // metadataCarrier 实现 propagation.TextMapCarrier 接口
 type metadataCarrier struct {
 	metadata.MD
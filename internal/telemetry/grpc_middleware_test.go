@@ -0,0 +1,248 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeServerStream 只提供上下文的 grpc.ServerStream
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeServerStream) Context() context.Context { return s.ctx }
+
+// messageStream 依次返回 pending 中的消息后返回 io.EOF，并统计发送的消息数
+type messageStream struct {
+	fakeServerStream
+	pending int
+	sent    int
+}
+
+func (s *messageStream) RecvMsg(m any) error {
+	if s.pending == 0 {
+		return io.EOF
+	}
+	s.pending--
+	return nil
+}
+
+func (s *messageStream) SendMsg(m any) error {
+	s.sent++
+	return nil
+}
+
+// attrValue 返回属性列表中指定键的值
+func attrValue(attrs []attribute.KeyValue, key string) (string, bool) {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value.Emit(), true
+		}
+	}
+	return "", false
+}
+
+func TestCaptureMetadata(t *testing.T) {
+	exporter := setupTestTracing(t)
+	core, logs := observer.New(zap.WarnLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+	sensitiveMetadataWarned.Delete("authorization")
+
+	g := NewGRPCMiddleware("grpc-test", WithCaptureMetadata("X-Tenant", "Authorization"))
+	handler := g.WrapUnaryHandler("Get", func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD{
+		"x-tenant":      []string{"a", "b"},
+		"authorization": []string{"Bearer token"},
+		"cookie":        []string{"session=1"},
+	})
+	if _, err := handler(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	span, ok := findSpan(exporter.GetSpans(), "Get")
+	if !ok {
+		t.Fatal("server span not exported")
+	}
+	if v, _ := attrValue(span.Attributes, "rpc.grpc.request.metadata.x-tenant"); v != "a,b" {
+		t.Errorf("x-tenant = %q, want multi-value metadata joined with commas", v)
+	}
+	if v, _ := attrValue(span.Attributes, "rpc.grpc.request.metadata.authorization"); v != "Bearer token" {
+		t.Errorf("authorization = %q, want the explicitly listed sensitive key captured", v)
+	}
+	if _, ok := attrValue(span.Attributes, "rpc.grpc.request.metadata.cookie"); ok {
+		t.Error("unlisted cookie metadata was captured")
+	}
+
+	// 同一敏感键只警告一次
+	NewGRPCMiddleware("grpc-test", WithCaptureMetadata("authorization"))
+	if n := logs.FilterMessage("Capturing sensitive gRPC metadata as span attribute").Len(); n != 1 {
+		t.Errorf("logged %d sensitive metadata warnings, want 1", n)
+	}
+}
+
+func TestGRPCStatusMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []GRPCOption
+		err  error
+		want codes.Code
+	}{
+		{"ok", nil, nil, codes.Ok},
+		{"client error", nil, status.Error(grpccodes.NotFound, "no such order"), codes.Unset},
+		{"server error", nil, status.Error(grpccodes.Internal, "db down"), codes.Error},
+		{"non-status error", nil, context.DeadlineExceeded, codes.Error},
+		{"custom unset codes", []GRPCOption{WithUnsetStatusCodes(grpccodes.Unavailable)}, status.Error(grpccodes.NotFound, "no such order"), codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := setupTestTracing(t)
+			g := NewGRPCMiddleware("grpc-test", tt.opts...)
+
+			unary := g.WrapUnaryHandler("Unary", func(context.Context, any) (any, error) { return nil, tt.err })
+			_, _ = unary(context.Background(), nil)
+			stream := g.WrapStreamHandler("Stream", func(any, grpc.ServerStream) error { return tt.err })
+			_ = stream(nil, fakeServerStream{ctx: context.Background()})
+
+			for _, name := range []string{"Unary", "Stream"} {
+				span, ok := findSpan(exporter.GetSpans(), name)
+				if !ok {
+					t.Fatalf("%s span not exported", name)
+				}
+				if span.Status.Code != tt.want {
+					t.Errorf("%s span status = %v, want %v", name, span.Status.Code, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPerMessageSpans(t *testing.T) {
+	exporter := setupTestTracing(t)
+	g := NewGRPCMiddleware("grpc-test", WithPerMessageSpans())
+
+	// 回显处理器：每收到一条消息发送一条，直到流结束
+	handler := g.WrapStreamHandler("Echo", func(srv any, stream grpc.ServerStream) error {
+		for {
+			if err := stream.RecvMsg(nil); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(nil); err != nil {
+				return err
+			}
+		}
+	})
+	stream := &messageStream{fakeServerStream: fakeServerStream{ctx: context.Background()}, pending: 3}
+	if err := handler(nil, stream); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	parent, ok := findSpan(spans, "Echo")
+	if !ok {
+		t.Fatal("stream span not exported")
+	}
+	ids := map[string][]string{}
+	for _, span := range spans {
+		if span.Name != "stream.recv" && span.Name != "stream.send" {
+			continue
+		}
+		if span.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("%s is not a child of the stream span", span.Name)
+		}
+		if span.Status.Code == codes.Error {
+			t.Errorf("%s has error status; io.EOF should end the stream normally", span.Name)
+		}
+		id, _ := attrValue(span.Attributes, "rpc.message.id")
+		ids[span.Name] = append(ids[span.Name], id)
+	}
+	// 3 条消息加上返回 io.EOF 的最后一次接收
+	if got := ids["stream.recv"]; len(got) != 4 || got[0] != "1" || got[3] != "4" {
+		t.Errorf("stream.recv message ids = %v, want 1..4", got)
+	}
+	if got := ids["stream.send"]; len(got) != 3 || got[0] != "1" || got[2] != "3" {
+		t.Errorf("stream.send message ids = %v, want 1..3", got)
+	}
+	if stream.sent != 3 {
+		t.Errorf("underlying stream sent %d messages, want 3", stream.sent)
+	}
+
+	// 未启用选项时不创建消息 span
+	exporter.Reset()
+	plain := NewGRPCMiddleware("grpc-test").WrapStreamHandler("Echo", func(srv any, stream grpc.ServerStream) error {
+		return stream.SendMsg(nil)
+	})
+	_ = plain(nil, &messageStream{fakeServerStream: fakeServerStream{ctx: context.Background()}})
+	if _, ok := findSpan(exporter.GetSpans(), "stream.send"); ok {
+		t.Error("stream.send span created without WithPerMessageSpans")
+	}
+}
+
+func TestGRPCSpanKinds(t *testing.T) {
+	exporter := setupTestTracing(t)
+	g := NewGRPCMiddleware("grpc-kind-test")
+
+	// 通过拦截器的真实调用：客户端与服务端 span
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer(g.ServerOptions()...)
+	coltracepb.RegisterTraceServiceServer(server, &fakeCollector{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()), g.DialOption())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if _, err := coltracepb.NewTraceServiceClient(conn).Export(context.Background(), &coltracepb.ExportTraceServiceRequest{}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// 手工包装的服务端处理器
+	unary := g.WrapUnaryHandler("Unary", func(context.Context, any) (any, error) { return nil, nil })
+	_, _ = unary(context.Background(), nil)
+	stream := g.WrapStreamHandler("Stream", func(any, grpc.ServerStream) error { return nil })
+	_ = stream(nil, fakeServerStream{ctx: context.Background()})
+
+	spans := exporter.GetSpans()
+	for name, kind := range map[string]trace.SpanKind{"Unary": trace.SpanKindServer, "Stream": trace.SpanKindServer} {
+		span, ok := findSpan(spans, name)
+		if !ok {
+			t.Fatalf("%s span not exported", name)
+		}
+		if span.SpanKind != kind {
+			t.Errorf("%s span kind = %s, want %s", name, span.SpanKind, kind)
+		}
+	}
+	kinds := map[trace.SpanKind]int{}
+	for _, span := range spans {
+		if span.Name == "opentelemetry.proto.collector.trace.v1.TraceService/Export" {
+			kinds[span.SpanKind]++
+		}
+	}
+	if kinds[trace.SpanKindClient] != 1 || kinds[trace.SpanKindServer] != 1 {
+		t.Errorf("Export span kinds = %v, want one client and one server span", kinds)
+	}
+}
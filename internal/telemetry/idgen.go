@@ -0,0 +1,38 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SequentialIDGenerator 按顺序生成 trace ID 与 span ID（均从 1 开始递增），仅用于测试中断言 span 之间的关系
+// 生成的 ID 不满足 W3C 对随机性的要求，不要在生产环境使用
+type SequentialIDGenerator struct {
+	traceSeq atomic.Uint64
+	spanSeq  atomic.Uint64
+}
+
+// NewSequentialIDGenerator 创建顺序 ID 生成器，通过 Config.IDGenerator 传入
+func NewSequentialIDGenerator() *SequentialIDGenerator {
+	return &SequentialIDGenerator{}
+}
+
+// NewIDs 返回新的 trace ID 与根 span ID
+func (g *SequentialIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var traceID trace.TraceID
+	binary.BigEndian.PutUint64(traceID[8:], g.traceSeq.Add(1))
+	return traceID, g.NewSpanID(ctx, traceID)
+}
+
+// NewSpanID 返回新的 span ID
+func (g *SequentialIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], g.spanSeq.Add(1))
+	return spanID
+}
+
+var _ sdktrace.IDGenerator = (*SequentialIDGenerator)(nil)
@@ -0,0 +1,39 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSequentialIDGenerator(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.IDGenerator = NewSequentialIDGenerator()
+	p := newTestProvider(t, cfg)
+	tracer := p.Tracer("idgen-test")
+
+	ctx, first := tracer.Start(context.Background(), "first")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	first.End()
+	_, second := tracer.Start(context.Background(), "second")
+	second.End()
+
+	tests := []struct {
+		name    string
+		span    trace.Span
+		traceID string
+		spanID  string
+	}{
+		{"first", first, "00000000000000000000000000000001", "0000000000000001"},
+		{"child", child, "00000000000000000000000000000001", "0000000000000002"},
+		{"second", second, "00000000000000000000000000000002", "0000000000000003"},
+	}
+	for _, tt := range tests {
+		sc := tt.span.SpanContext()
+		if sc.TraceID().String() != tt.traceID || sc.SpanID().String() != tt.spanID {
+			t.Errorf("%s IDs = %s/%s, want %s/%s", tt.name, sc.TraceID(), sc.SpanID(), tt.traceID, tt.spanID)
+		}
+	}
+}
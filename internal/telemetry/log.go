@@ -2,16 +2,44 @@ package telemetry
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"syscall"
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// unsampledLogLevel 未采样 trace 中保留的最低日志级别，为 nil 时不按 trace 采样过滤日志
+var unsampledLogLevel atomic.Pointer[zapcore.Level]
+
+// logBaggageKeys LoggerWithContext 从 baggage 中读取并添加为日志字段的键
+var logBaggageKeys atomic.Pointer[[]string]
+
 // LogProvider 封装日志 provider 和 cleanup 函数
 type LogProvider struct {
-	logger *zap.Logger
+	logger         *zap.Logger
+	level          zap.AtomicLevel
+	errorSink      zapcore.WriteSyncer
+	closeErrorSink func()
+	closeSink      func()
+}
+
+// resolveLogSink 返回实际使用的日志输出目标，为空时使用 stdout；
+// 不支持的取值同样回退到 stdout，第二个返回值为 false 以便输出警告
+func resolveLogSink(sink string) (string, bool) {
+	switch sink {
+	case "":
+		return "stdout", true
+	case "stdout", "stderr", "file", "syslog":
+		return sink, true
+	default:
+		return "stdout", false
+	}
 }
 
 // SetupLogging 配置日志功能
@@ -31,6 +59,15 @@ func SetupLogging(cfg Config) (*LogProvider, error) {
 		zapCfg.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
 	}
 
+	// 显式配置的日志级别优先于环境默认值
+	if cfg.LogLevel != "" {
+		level, err := zapcore.ParseLevel(cfg.LogLevel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", cfg.LogLevel, err)
+		}
+		zapCfg.Level.SetLevel(level)
+	}
+
 	// 添加默认字段
 	zapCfg.InitialFields = map[string]interface{}{
 		"service": cfg.ServiceName,
@@ -38,26 +75,138 @@ func SetupLogging(cfg Config) (*LogProvider, error) {
 		"env":     cfg.Environment,
 	}
 
-	// 创建日志记录器
-	logger, err := zapCfg.Build(
+	opts := []zap.Option{
 		zap.AddCallerSkip(1),
 		zap.WithCaller(true),
+	}
+
+	// 配置日志输出目标
+	var closeSink func()
+	logSink, knownSink := resolveLogSink(cfg.LogSink)
+	switch logSink {
+	case "stdout", "stderr":
+		zapCfg.OutputPaths = []string{logSink}
+	case "file":
+		if cfg.LogFilePath == "" {
+			return nil, fmt.Errorf("log file path is required for file log sink")
+		}
+		zapCfg.OutputPaths = []string{cfg.LogFilePath}
+	case "syslog":
+		core, closeFn, err := newSyslogCore(cfg, zapCfg.EncoderConfig, zapCfg.Level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create syslog core: %w", err)
+		}
+		closeSink = closeFn
+		opts = append(opts, zap.WrapCore(func(zapcore.Core) zapcore.Core {
+			return core
+		}))
+	}
+
+	// 将 Error 及以上级别的日志额外写入独立的错误日志文件
+	var (
+		errorSink      zapcore.WriteSyncer
+		closeErrorSink func()
 	)
+	if cfg.ErrorLogPath != "" {
+		sink, closeFn, err := zap.Open(cfg.ErrorLogPath)
+		if err != nil {
+			if closeSink != nil {
+				closeSink()
+			}
+			return nil, fmt.Errorf("failed to open error log file: %w", err)
+		}
+		errorSink, closeErrorSink = sink, closeFn
+
+		errorCore := zapcore.NewCore(
+			zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+			errorSink,
+			zapcore.ErrorLevel,
+		)
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, errorCore)
+		}))
+	}
+
+	// 按 trace 缓存低于日志级别的日志，仅在该 trace 出现 Error 日志时输出
+	// 缓冲的生命周期由 span 处理器按本地根 span 管理（见 traceLogBufferProcessor）
+	var buffer *traceLogBuffer
+	if cfg.TraceScopedDebugBuffer {
+		buffer = newTraceLogBuffer(cfg.TraceDebugBufferSize, cfg.TraceDebugMaxTraces)
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return newTraceBufferCore(core, buffer)
+		}))
+	}
+
+	// 创建日志记录器
+	logger, err := zapCfg.Build(opts...)
 	if err != nil {
+		if closeErrorSink != nil {
+			closeErrorSink()
+		}
+		if closeSink != nil {
+			closeSink()
+		}
 		return nil, err
 	}
 
+	if !knownSink {
+		logger.Warn("Unsupported log sink, falling back to stdout", zap.String("sink", cfg.LogSink))
+	}
+
 	// 替换全局 logger
 	zap.ReplaceGlobals(logger)
+	traceDebugBuffer.Store(buffer)
+
+	// 配置日志采样与 trace 采样的绑定
+	if cfg.SampleLogsWithTrace {
+		level, err := zapcore.ParseLevel(cfg.UnsampledLogLevel)
+		if err != nil {
+			level = zapcore.WarnLevel
+		}
+		// warn/error 始终保留
+		if level > zapcore.WarnLevel {
+			level = zapcore.WarnLevel
+		}
+		unsampledLogLevel.Store(&level)
+	} else {
+		unsampledLogLevel.Store(nil)
+	}
+
+	// 配置需要添加到日志字段的 baggage 键
+	keys := append([]string(nil), cfg.LogBaggageKeys...)
+	logBaggageKeys.Store(&keys)
 
 	return &LogProvider{
-		logger: logger,
+		logger:         logger,
+		level:          zapCfg.Level,
+		errorSink:      errorSink,
+		closeErrorSink: closeErrorSink,
+		closeSink:      closeSink,
 	}, nil
 }
 
-// Shutdown 关闭日志系统
+// Shutdown 关闭日志系统，刷新主输出与错误日志文件
 func (lp *LogProvider) Shutdown() error {
-	return lp.logger.Sync()
+	err := syncLogOutput(lp.logger.Sync())
+	if lp.errorSink != nil {
+		if syncErr := syncLogOutput(lp.errorSink.Sync()); syncErr != nil && err == nil {
+			err = syncErr
+		}
+		lp.closeErrorSink()
+	}
+	if lp.closeSink != nil {
+		lp.closeSink()
+	}
+	return err
+}
+
+// syncLogOutput 过滤刷新日志输出时的预期错误：stdout/stderr 为终端或管道时不支持 fsync，
+// 返回 EINVAL/ENOTTY，不代表日志丢失
+func syncLogOutput(err error) error {
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		return nil
+	}
+	return err
 }
 
 // Logger 获取日志记录器
@@ -73,13 +222,13 @@ func LoggerWithContext(ctx context.Context) *zap.Logger {
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		sc := span.SpanContext()
-		logger = logger.With(
+		logger = withTraceSampling(logger, sc).With(
 			zap.String("trace_id", sc.TraceID().String()),
 			zap.String("span_id", sc.SpanID().String()),
 		)
 	}
 
-	return logger
+	return withBaggageFields(logger, ctx)
 }
 
 // LoggerWithTraceContext 创建带有追踪上下文的日志记录器
@@ -92,13 +241,79 @@ func LoggerWithTraceContext(parent *zap.Logger, ctx context.Context) *zap.Logger
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		sc := span.SpanContext()
-		return parent.With(
+		parent = withTraceSampling(parent, sc).With(
 			zap.String("trace_id", sc.TraceID().String()),
 			zap.String("span_id", sc.SpanID().String()),
 		)
 	}
 
-	return parent
+	return withBaggageFields(parent, ctx)
+}
+
+// TraceFields 以字符串 map 返回当前 span 的 trace_id、span_id 与 trace_flags，
+// 供只接受 map[string]string 字段的第三方日志库关联 trace；上下文中没有有效 span 时返回空 map
+func TraceFields(ctx context.Context) map[string]string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"trace_id":    sc.TraceID().String(),
+		"span_id":     sc.SpanID().String(),
+		"trace_flags": sc.TraceFlags().String(),
+	}
+}
+
+// withBaggageFields 将 LogBaggageKeys 中列出的 baggage 条目添加为日志字段，不存在的键被跳过
+func withBaggageFields(logger *zap.Logger, ctx context.Context) *zap.Logger {
+	keys := logBaggageKeys.Load()
+	if keys == nil || len(*keys) == 0 {
+		return logger
+	}
+
+	bag := baggage.FromContext(ctx)
+	var fields []zap.Field
+	for _, key := range *keys {
+		if member := bag.Member(key); member.Key() != "" {
+			fields = append(fields, zap.String(key, member.Value()))
+		}
+	}
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
+
+// withTraceSampling 当启用日志采样且 trace 未被采样时，过滤低于阈值的日志
+func withTraceSampling(logger *zap.Logger, sc trace.SpanContext) *zap.Logger {
+	level := unsampledLogLevel.Load()
+	if level == nil || sc.IsSampled() {
+		return logger
+	}
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &minLevelCore{Core: core, minLevel: *level}
+	}))
+}
+
+// minLevelCore 丢弃低于 minLevel 的日志条目
+type minLevelCore struct {
+	zapcore.Core
+	minLevel zapcore.Level
+}
+
+func (c *minLevelCore) Enabled(level zapcore.Level) bool {
+	return level >= c.minLevel && c.Core.Enabled(level)
+}
+
+func (c *minLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &minLevelCore{Core: c.Core.With(fields), minLevel: c.minLevel}
+}
+
+func (c *minLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.minLevel {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
 }
 
 // AddSpanAttributes 为当前 span 添加属性
@@ -0,0 +1,228 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// traceDebugBuffer SetupLogging 创建的按 trace 缓存日志的缓冲，未启用 TraceScopedDebugBuffer 时为 nil
+var traceDebugBuffer atomic.Pointer[traceLogBuffer]
+
+// traceLogBuffer 按 trace 缓存低于日志级别的日志条目，trace 中出现 Error 日志时整体输出，
+// 否则在该 trace 的本地根 span 结束时丢弃
+// 只缓存本地根 span 尚未结束的 trace（由 traceLogBufferProcessor 登记），其余 trace 的低级别日志照常被过滤
+// 内存上限为 maxTraces * perTrace 条日志：单个 trace 超出 perTrace 时丢弃最旧的条目（环形缓冲），
+// trace 数超出 maxTraces 时淘汰最早开始缓存的 trace
+type traceLogBuffer struct {
+	mu        sync.Mutex
+	perTrace  int
+	maxTraces int
+	traces    map[string]*logRing
+	order     []string
+	// 本地根 span 尚未结束的 trace，值为进行中的本地根 span 数
+	active map[string]int
+}
+
+// bufferedLogEntry 缓存的日志条目，记录写入时使用的 core 以保留 With 添加的字段
+type bufferedLogEntry struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// logRing 单个 trace 的环形缓冲
+type logRing struct {
+	entries []bufferedLogEntry
+	next    int
+	full    bool
+}
+
+func newTraceLogBuffer(perTrace, maxTraces int) *traceLogBuffer {
+	if perTrace <= 0 {
+		perTrace = 256
+	}
+	if maxTraces <= 0 {
+		maxTraces = 1024
+	}
+	return &traceLogBuffer{
+		perTrace:  perTrace,
+		maxTraces: maxTraces,
+		traces:    make(map[string]*logRing),
+		active:    make(map[string]int),
+	}
+}
+
+// begin 登记 trace 的一个本地根 span 开始
+func (b *traceLogBuffer) begin(traceID string) {
+	b.mu.Lock()
+	b.active[traceID]++
+	b.mu.Unlock()
+}
+
+// end 登记 trace 的一个本地根 span 结束，最后一个结束时丢弃该 trace 的缓冲
+func (b *traceLogBuffer) end(traceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.active[traceID] > 1 {
+		b.active[traceID]--
+		return
+	}
+	delete(b.active, traceID)
+	b.remove(traceID)
+}
+
+// tracking 判断 trace 的日志是否应被缓存
+func (b *traceLogBuffer) tracking(traceID string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active[traceID] > 0
+}
+
+// remove 移除 trace 的缓冲并返回，调用方需持有 mu
+func (b *traceLogBuffer) remove(traceID string) (*logRing, bool) {
+	ring, ok := b.traces[traceID]
+	if !ok {
+		return nil, false
+	}
+	delete(b.traces, traceID)
+	for i, id := range b.order {
+		if id == traceID {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			break
+		}
+	}
+	return ring, true
+}
+
+// add 将条目加入 trace 的缓冲
+func (b *traceLogBuffer) add(traceID string, e bufferedLogEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ring, ok := b.traces[traceID]
+	if !ok {
+		for len(b.order) >= b.maxTraces {
+			delete(b.traces, b.order[0])
+			b.order = b.order[1:]
+		}
+		ring = &logRing{entries: make([]bufferedLogEntry, 0, b.perTrace)}
+		b.traces[traceID] = ring
+		b.order = append(b.order, traceID)
+	}
+
+	if len(ring.entries) < b.perTrace {
+		ring.entries = append(ring.entries, e)
+		return
+	}
+	ring.entries[ring.next] = e
+	ring.next = (ring.next + 1) % b.perTrace
+	ring.full = true
+}
+
+// flush 按时间顺序输出并清除 trace 的缓冲
+func (b *traceLogBuffer) flush(traceID string) {
+	b.mu.Lock()
+	ring, ok := b.remove(traceID)
+	b.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	entries := ring.entries
+	if ring.full {
+		entries = append(entries[ring.next:len(entries):len(entries)], entries[:ring.next]...)
+	}
+	for _, e := range entries {
+		// 直接写入底层 core，绕过级别检查
+		_ = e.core.Write(e.entry, e.fields)
+	}
+}
+
+// traceBufferCore 对带有 trace_id 字段的 logger 缓存低于日志级别的条目
+// trace_id 由 LoggerWithContext/LoggerWithTraceContext 通过 With 添加；
+// 未关联 trace 的日志与达到日志级别的日志照常输出
+type traceBufferCore struct {
+	zapcore.Core
+	buffer  *traceLogBuffer
+	traceID string
+}
+
+func newTraceBufferCore(core zapcore.Core, buffer *traceLogBuffer) zapcore.Core {
+	return &traceBufferCore{Core: core, buffer: buffer}
+}
+
+// Enabled 低于日志级别的条目仅在 trace 的本地根 span 尚未结束时启用（以便缓存）
+func (c *traceBufferCore) Enabled(level zapcore.Level) bool {
+	return c.Core.Enabled(level) || c.buffering()
+}
+
+// buffering 判断当前 logger 关联的 trace 是否正在缓存日志
+func (c *traceBufferCore) buffering() bool {
+	return c.traceID != "" && c.buffer.tracking(c.traceID)
+}
+
+func (c *traceBufferCore) With(fields []zapcore.Field) zapcore.Core {
+	traceID := c.traceID
+	for _, f := range fields {
+		if f.Key == "trace_id" && f.Type == zapcore.StringType {
+			traceID = f.String
+		}
+	}
+	return &traceBufferCore{Core: c.Core.With(fields), buffer: c.buffer, traceID: traceID}
+}
+
+func (c *traceBufferCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.traceID == "" {
+		return c.Core.Check(ent, ce)
+	}
+	if c.Core.Enabled(ent.Level) {
+		if ent.Level >= zapcore.ErrorLevel {
+			// 先输出缓存的条目，保证其出现在错误日志之前
+			c.buffer.flush(c.traceID)
+		}
+		return c.Core.Check(ent, ce)
+	}
+	if !c.buffering() {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+// Write 仅在条目低于日志级别时被调用（见 Check），将其缓存
+func (c *traceBufferCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.buffer.add(c.traceID, bufferedLogEntry{
+		core:   c.Core,
+		entry:  ent,
+		fields: append([]zapcore.Field(nil), fields...),
+	})
+	return nil
+}
+
+// traceLogBufferProcessor 在本地根 span（无父 span 或父 span 来自远端）开始时开始缓存该 trace 的日志，
+// 结束时丢弃未输出的缓冲，使缓冲的生命周期与请求一致
+type traceLogBufferProcessor struct{}
+
+// isLocalRoot 判断 span 是否为本进程内 trace 的根
+func isLocalRoot(s sdktrace.ReadOnlySpan) bool {
+	return !s.Parent().IsValid() || s.Parent().IsRemote()
+}
+
+func (traceLogBufferProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	if b := traceDebugBuffer.Load(); b != nil && isLocalRoot(s) {
+		b.begin(s.SpanContext().TraceID().String())
+	}
+}
+
+func (traceLogBufferProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if b := traceDebugBuffer.Load(); b != nil && isLocalRoot(s) {
+		b.end(s.SpanContext().TraceID().String())
+	}
+}
+
+func (traceLogBufferProcessor) Shutdown(context.Context) error { return nil }
+
+func (traceLogBufferProcessor) ForceFlush(context.Context) error { return nil }
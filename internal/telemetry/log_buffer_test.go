@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// setupTraceLogBuffer 返回写入 info 级别观察器的缓冲 logger，以及挂载缓冲处理器的 tracer
+func setupTraceLogBuffer(t *testing.T) (*zap.Logger, *observer.ObservedLogs, *traceLogBuffer, trace.Tracer) {
+	t.Helper()
+	core, logs := observer.New(zapcore.InfoLevel)
+	buffer := newTraceLogBuffer(10, 10)
+	prev := traceDebugBuffer.Swap(buffer)
+	t.Cleanup(func() { traceDebugBuffer.Store(prev) })
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(traceLogBufferProcessor{}))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	return zap.New(newTraceBufferCore(core, buffer)), logs, buffer, tp.Tracer("test")
+}
+
+// messages 返回观察到的日志消息
+func messages(logs *observer.ObservedLogs) []string {
+	var msgs []string
+	for _, e := range logs.All() {
+		msgs = append(msgs, e.Message)
+	}
+	return msgs
+}
+
+func TestTraceLogBufferFlushesOnError(t *testing.T) {
+	logger, logs, _, tracer := setupTraceLogBuffer(t)
+	ctx, root := tracer.Start(context.Background(), "request")
+	defer root.End()
+	_, child := tracer.Start(ctx, "db")
+	defer child.End()
+
+	l := logger.With(zap.String("trace_id", root.SpanContext().TraceID().String()))
+	l.Debug("step 1")
+	l.Debug("step 2")
+	if logs.Len() != 0 {
+		t.Fatalf("debug entries written before an error: %v", messages(logs))
+	}
+
+	l.Error("failed")
+	got := messages(logs)
+	if len(got) != 3 || got[0] != "step 1" || got[1] != "step 2" || got[2] != "failed" {
+		t.Errorf("logged %v, want buffered debug entries followed by the error", got)
+	}
+}
+
+func TestTraceLogBufferDiscardsWhenRootSpanEnds(t *testing.T) {
+	logger, logs, buffer, tracer := setupTraceLogBuffer(t)
+	ctx, root := tracer.Start(context.Background(), "request")
+	traceID := root.SpanContext().TraceID().String()
+	l := logger.With(zap.String("trace_id", traceID))
+
+	l.Debug("step")
+	// 子 span 结束不释放缓冲
+	_, child := tracer.Start(ctx, "db")
+	child.End()
+	if !l.Core().Enabled(zapcore.DebugLevel) {
+		t.Fatal("debug disabled while the root span is running")
+	}
+
+	root.End()
+	buffer.mu.Lock()
+	_, buffered := buffer.traces[traceID]
+	buffer.mu.Unlock()
+	if buffered {
+		t.Error("ring still held after the root span ended")
+	}
+	if l.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("debug still enabled after the root span ended")
+	}
+
+	l.Error("late")
+	if got := messages(logs); len(got) != 1 || got[0] != "late" {
+		t.Errorf("logged %v, want only the late error", got)
+	}
+}
+
+func TestTraceLogBufferIgnoresUntrackedTraces(t *testing.T) {
+	logger, logs, _, _ := setupTraceLogBuffer(t)
+	l := logger.With(zap.String("trace_id", "0123456789abcdef0123456789abcdef"))
+	if l.Core().Enabled(zapcore.DebugLevel) {
+		t.Error("debug enabled for a trace without a running root span")
+	}
+	l.Debug("dropped")
+	l.Error("failed")
+	if got := messages(logs); len(got) != 1 || got[0] != "failed" {
+		t.Errorf("logged %v, want only the error", got)
+	}
+}
@@ -0,0 +1,105 @@
+//go:build !windows && !plan9
+
+package telemetry
+
+import (
+	"fmt"
+	"log/syslog"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogFacilities facility 名称到 syslog 优先级的映射
+var syslogFacilities = map[string]syslog.Priority{
+	"user":   syslog.LOG_USER,
+	"daemon": syslog.LOG_DAEMON,
+	"local0": syslog.LOG_LOCAL0,
+	"local1": syslog.LOG_LOCAL1,
+	"local2": syslog.LOG_LOCAL2,
+	"local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4,
+	"local5": syslog.LOG_LOCAL5,
+	"local6": syslog.LOG_LOCAL6,
+	"local7": syslog.LOG_LOCAL7,
+}
+
+// newSyslogCore 创建写入本地 syslog/journald 的 zap core
+func newSyslogCore(cfg Config, encCfg zapcore.EncoderConfig, level zapcore.LevelEnabler) (zapcore.Core, func(), error) {
+	facility, ok := syslogFacilities[cfg.SyslogFacility]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported syslog facility %q", cfg.SyslogFacility)
+	}
+
+	writer, err := syslog.New(facility|syslog.LOG_INFO, cfg.ServiceName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// syslog 自带时间戳
+	encCfg.TimeKey = ""
+
+	core := &syslogCore{
+		LevelEnabler: level,
+		enc:          zapcore.NewJSONEncoder(encCfg),
+		writer:       writer,
+	}
+	return core, func() { _ = writer.Close() }, nil
+}
+
+// syslogCore 将 zap 日志按级别映射为 syslog 优先级写出
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc    zapcore.Encoder
+	writer *syslog.Writer
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &syslogCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          c.enc.Clone(),
+		writer:       c.writer,
+	}
+	for _, field := range fields {
+		field.AddTo(clone.enc)
+	}
+	return clone
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	msg := buf.String()
+	buf.Free()
+
+	switch ent.Level {
+	case zapcore.DebugLevel:
+		return c.writer.Debug(msg)
+	case zapcore.InfoLevel:
+		return c.writer.Info(msg)
+	case zapcore.WarnLevel:
+		return c.writer.Warning(msg)
+	case zapcore.ErrorLevel:
+		return c.writer.Err(msg)
+	case zapcore.DPanicLevel:
+		return c.writer.Crit(msg)
+	case zapcore.PanicLevel:
+		return c.writer.Alert(msg)
+	case zapcore.FatalLevel:
+		return c.writer.Emerg(msg)
+	default:
+		return c.writer.Info(msg)
+	}
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
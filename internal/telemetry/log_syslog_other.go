@@ -0,0 +1,14 @@
+//go:build windows || plan9
+
+package telemetry
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newSyslogCore 当前平台不支持 syslog
+func newSyslogCore(cfg Config, encCfg zapcore.EncoderConfig, level zapcore.LevelEnabler) (zapcore.Core, func(), error) {
+	return nil, nil, fmt.Errorf("syslog log sink is not supported on this platform")
+}
@@ -0,0 +1,110 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerWithContextBaggageFields(t *testing.T) {
+	setupTestTracing(t)
+	core, logs := observer.New(zap.InfoLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+	keys := []string{"tenant.id", "region"}
+	prev := logBaggageKeys.Swap(&keys)
+	t.Cleanup(func() { logBaggageKeys.Store(prev) })
+
+	tenant, _ := baggage.NewMember("tenant.id", "acme")
+	user, _ := baggage.NewMember("user.id", "42")
+	bag, _ := baggage.New(tenant, user)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+	ctx, span := Tracer("log-test").Start(ctx, "request")
+	defer span.End()
+
+	LoggerWithContext(ctx).Info("handled")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["tenant.id"] != "acme" {
+		t.Errorf("tenant.id = %v, want acme", fields["tenant.id"])
+	}
+	if fields["trace_id"] != span.SpanContext().TraceID().String() {
+		t.Errorf("trace_id = %v, want %s", fields["trace_id"], span.SpanContext().TraceID())
+	}
+	// 未列出的键与不存在的键不添加
+	for _, key := range []string{"user.id", "region"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("unexpected field %s = %v", key, fields[key])
+		}
+	}
+}
+
+func TestResolveLogSink(t *testing.T) {
+	tests := []struct {
+		sink  string
+		want  string
+		known bool
+	}{
+		{sink: "", want: "stdout", known: true},
+		{sink: "stdout", want: "stdout", known: true},
+		{sink: "stderr", want: "stderr", known: true},
+		{sink: "syslog", want: "syslog", known: true},
+		{sink: "journald", want: "stdout", known: false},
+	}
+	for _, tt := range tests {
+		got, known := resolveLogSink(tt.sink)
+		if got != tt.want || known != tt.known {
+			t.Errorf("resolveLogSink(%q) = %q, %v, want %q, %v", tt.sink, got, known, tt.want, tt.known)
+		}
+	}
+}
+
+func TestSetupLoggingUnknownSinkFallsBackToStdout(t *testing.T) {
+	prev := zap.L()
+	t.Cleanup(func() { zap.ReplaceGlobals(prev) })
+
+	cfg := testProviderConfig()
+	cfg.LogSink = "journald"
+	lp, err := SetupLogging(cfg)
+	if err != nil {
+		t.Fatalf("SetupLogging with unknown sink: %v", err)
+	}
+	_ = lp.Shutdown()
+
+	if got := DefaultConfig().LogSink; got != "stdout" {
+		t.Errorf("default LogSink = %q, want stdout", got)
+	}
+}
+
+func TestTraceFields(t *testing.T) {
+	if fields := TraceFields(context.Background()); fields == nil || len(fields) != 0 {
+		t.Errorf("TraceFields without a span = %v, want an empty map", fields)
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x0a},
+		SpanID:     trace.SpanID{0x0b},
+		TraceFlags: trace.FlagsSampled,
+	})
+	fields := TraceFields(trace.ContextWithSpanContext(context.Background(), sc))
+	want := map[string]string{
+		"trace_id":    sc.TraceID().String(),
+		"span_id":     sc.SpanID().String(),
+		"trace_flags": "01",
+	}
+	if len(fields) != len(want) {
+		t.Errorf("TraceFields = %v, want %v", fields, want)
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %q, want %q", k, fields[k], v)
+		}
+	}
+}
@@ -5,21 +5,23 @@ import (
     "fmt"
     "time"
 
+    "github.com/prometheus/client_golang/prometheus"
     "go.opentelemetry.io/contrib/instrumentation/runtime"
     "go.opentelemetry.io/otel"
     "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+    "go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
     "go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
     "go.opentelemetry.io/otel/sdk/metric"
     "go.opentelemetry.io/otel/sdk/metric/reader"
-    "google.golang.org/grpc"
-    "google.golang.org/grpc/credentials"
-    "google.golang.org/grpc/credentials/insecure"
+    "go.uber.org/zap"
 )
 
 // MetricProvider 封装 metric provider 和 cleanup 函数（新 API）
 type MetricProvider struct {
     meterProvider *metric.MeterProvider
     cleanup       func() error
+    promRegistry  *prometheus.Registry
+    promNamespace string
 }
 
 // SetupMetrics 配置指标监控功能（基于新 reader/view 架构）
@@ -34,6 +36,15 @@ func SetupMetrics(cfg Config) (*MetricProvider, error) {
         return nil, fmt.Errorf("failed to create resource: %w", err)
     }
 
+    // 按 instrument 类型配置聚合时间性
+    var temporalitySelector metric.TemporalitySelector
+    if len(cfg.TemporalityByKind) > 0 {
+        temporalitySelector, err = newTemporalitySelector(cfg.TemporalityByKind)
+        if err != nil {
+            return nil, err
+        }
+    }
+
     // 构造 readers（每个导出器一个 reader）与清理函数链
     var (
         readers []metric.Reader
@@ -42,9 +53,14 @@ func SetupMetrics(cfg Config) (*MetricProvider, error) {
 
     // 控制台导出器
     if cfg.EnableConsoleExporter {
-        consoleExporter, err := stdoutmetric.New(
-            stdoutmetric.WithPrettyPrint(),
-        )
+        consoleOpts := []stdoutmetric.Option{stdoutmetric.WithWriter(consoleWriter)}
+        if !cfg.ConsoleExporterCompact {
+            consoleOpts = append(consoleOpts, stdoutmetric.WithPrettyPrint())
+        }
+        if temporalitySelector != nil {
+            consoleOpts = append(consoleOpts, stdoutmetric.WithTemporalitySelector(temporalitySelector))
+        }
+        consoleExporter, err := stdoutmetric.New(consoleOpts...)
         if err != nil {
             return nil, fmt.Errorf("failed to create stdout metric exporter: %w", err)
         }
@@ -63,54 +79,71 @@ func SetupMetrics(cfg Config) (*MetricProvider, error) {
         }
     }
 
-    // OTLP 导出器
+    // OTLP 导出器（gRPC 或 HTTP，见 Config.OTLPProtocol）
     if cfg.OTLPEndpoint != "" {
-        ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-        defer cancel()
+        endpoint, err := parseOTLPEndpoint(cfg.OTLPEndpoint, cfg.OTLPProtocol)
+        if err != nil {
+            return nil, err
+        }
 
-        // 配置 gRPC 连接选项
-        var grpcOpts []grpc.DialOption
-        
-        // 配置 TLS 凭据
-        if cfg.TLSConfig.Enabled {
-            tlsConfig, err := createTLSConfig(cfg.TLSConfig)
+        var (
+            otlpExporter metric.Exporter
+            // 清理时关闭导出器；HTTP 导出器由 reader 随 provider 一并关闭，重复关闭会返回错误，因此为空操作
+            shutdownExporter = func(context.Context) error { return nil }
+            // 关闭 gRPC 连接，HTTP 协议下为空操作
+            closeConn = func() error { return nil }
+        )
+        if endpoint.protocol == otlpProtocolHTTP {
+            httpOpts, err := newOTLPMetricHTTPOptions(cfg, endpoint, temporalitySelector)
+            if err != nil {
+                return nil, err
+            }
+            otlpExporter, err = otlpmetrichttp.New(context.Background(), httpOpts...)
             if err != nil {
-                return nil, fmt.Errorf("failed to create TLS config: %w", err)
+                return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
             }
-            grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
         } else {
-            grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-        }
-        
-        grpcOpts = append(grpcOpts, grpc.WithBlock())
+            conn, err := dialOTLP(cfg, "metrics")
+            if err != nil {
+                return nil, err
+            }
 
-        conn, err := grpc.DialContext(ctx, cfg.OTLPEndpoint, grpcOpts...)
-        if err != nil {
-            return nil, fmt.Errorf("failed to connect to OTLP endpoint: %w", err)
-        }
+            // 配置 OTLP 客户端选项
+            var clientOpts []otlpmetricgrpc.Option
+            clientOpts = append(clientOpts, otlpmetricgrpc.WithGRPCConn(conn))
+
+            // 配置单次导出超时
+            if cfg.OTLPExportTimeout > 0 {
+                clientOpts = append(clientOpts, otlpmetricgrpc.WithTimeout(cfg.OTLPExportTimeout))
+            }
 
-        // 配置 OTLP 客户端选项
-        var clientOpts []otlpmetricgrpc.Option
-        clientOpts = append(clientOpts, otlpmetricgrpc.WithGRPCConn(conn))
+            // 配置聚合时间性
+            if temporalitySelector != nil {
+                clientOpts = append(clientOpts, otlpmetricgrpc.WithTemporalitySelector(temporalitySelector))
+            }
         
-        // 配置重试选项
-        if cfg.RetryConfig.Enabled {
-            clientOpts = append(clientOpts, otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig{
-                Enabled:         true,
-                InitialInterval: cfg.RetryConfig.InitialInterval,
-                MaxInterval:     cfg.RetryConfig.MaxInterval,
-                MaxElapsedTime:  cfg.RetryConfig.MaxElapsedTime,
-                Multiplier:      cfg.RetryConfig.Multiplier,
-                RandomizationFactor: cfg.RetryConfig.RandomizationFactor,
-            }))
-        }
+            // 配置重试选项
+            if cfg.RetryConfig.Enabled {
+                clientOpts = append(clientOpts, otlpmetricgrpc.WithRetry(otlpmetricgrpc.RetryConfig{
+                    Enabled:         true,
+                    InitialInterval: cfg.RetryConfig.InitialInterval,
+                    MaxInterval:     cfg.RetryConfig.MaxInterval,
+                    MaxElapsedTime:  cfg.RetryConfig.MaxElapsedTime,
+                    Multiplier:      cfg.RetryConfig.Multiplier,
+                    RandomizationFactor: cfg.RetryConfig.RandomizationFactor,
+                }))
+            }
 
-        otlpExporter, err := otlpmetricgrpc.New(
-            context.Background(),
-            clientOpts...,
-        )
-        if err != nil {
-            return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+            otlpExporter, err = otlpmetricgrpc.New(
+                context.Background(),
+                clientOpts...,
+            )
+            if err != nil {
+                conn.Close()
+                return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+            }
+            shutdownExporter = otlpExporter.Shutdown
+            closeConn = conn.Close
         }
         readers = append(readers, reader.NewPeriodic(
             otlpExporter,
@@ -118,13 +151,33 @@ func SetupMetrics(cfg Config) (*MetricProvider, error) {
         ))
         prev := cleanup
         cleanup = func() error {
+            var err error
             if prev != nil {
-                if err := prev(); err != nil {
-                    return err
-                }
+                err = prev()
+            }
+            if shutdownErr := shutdownExporter(context.Background()); shutdownErr != nil && err == nil {
+                err = shutdownErr
+            }
+            // 导出器不会关闭通过 WithGRPCConn 传入的连接，需一并关闭，连接监视 goroutine 随之退出
+            if closeErr := closeConn(); closeErr != nil && err == nil {
+                err = closeErr
+            }
+            return err
+        }
+    }
+
+    // Prometheus 导出器（拉模式）
+    var promRegistry *prometheus.Registry
+    if cfg.EnablePrometheusExporter {
+        promReader, registry, err := newPrometheusReader(cfg)
+        if err != nil {
+            if cleanup != nil {
+                _ = cleanup()
             }
-            return otlpExporter.Shutdown(context.Background())
+            return nil, err
         }
+        readers = append(readers, promReader)
+        promRegistry = registry
     }
 
     if len(readers) == 0 {
@@ -142,17 +195,21 @@ func SetupMetrics(cfg Config) (*MetricProvider, error) {
     // 设置全局 provider
     otel.SetMeterProvider(mp)
 
-    // 启用 runtime 指标
-    if err := runtime.Start(
-        runtime.WithMinimumReadMemStatsInterval(time.Second),
-        runtime.WithMeterProvider(mp),
-    ); err != nil {
-        return nil, fmt.Errorf("failed to start runtime metrics: %w", err)
+    // 启用 runtime 指标（可选，失败时仅告警）
+    if cfg.EnableRuntimeMetrics {
+        if err := runtime.Start(
+            runtime.WithMinimumReadMemStatsInterval(time.Second),
+            runtime.WithMeterProvider(mp),
+        ); err != nil {
+            Logger().Warn("Failed to start runtime metrics, continuing without them", zap.Error(err))
+        }
     }
 
     return &MetricProvider{
         meterProvider: mp,
         cleanup:       cleanup,
+        promRegistry:  promRegistry,
+        promNamespace: cfg.PrometheusNamespace,
     }, nil
 }
 
@@ -169,9 +226,9 @@ func (mp *MetricProvider) Shutdown(ctx context.Context) error {
     return nil
 }
 
-// Meter 通过全局 provider 获取 meter
+// Meter 通过全局 provider 获取 meter，使用默认的 instrumentation scope 版本（见 SetDefaultInstrumentationVersion）
 func Meter(name string) metric.Meter {
-    return otel.Meter(name)
+    return MeterVersioned(name, defaultScopeVersion.Load().(string))
 }
 
 
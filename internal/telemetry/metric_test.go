@@ -0,0 +1,83 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+// setupTestMetricProvider 以 Prometheus 导出器调用 SetupMetrics，测试结束时关闭并恢复全局 provider
+func setupTestMetricProvider(t *testing.T, enableRuntime bool) *MetricProvider {
+	t.Helper()
+	cfg := testProviderConfig()
+	cfg.EnableRuntimeMetrics = enableRuntime
+
+	prev := otel.GetMeterProvider()
+	mp, err := SetupMetrics(cfg)
+	if err != nil {
+		t.Fatalf("SetupMetrics: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = mp.Shutdown(context.Background())
+		otel.SetMeterProvider(prev)
+	})
+	return mp
+}
+
+// scrapeMetrics 读取 MetricProvider 的 Prometheus 端点输出
+func scrapeMetrics(mp *MetricProvider) string {
+	rec := httptest.NewRecorder()
+	mp.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestSetupMetricsRuntimeMetrics(t *testing.T) {
+	if body := scrapeMetrics(setupTestMetricProvider(t, true)); !strings.Contains(body, "go_goroutine") {
+		t.Errorf("runtime metrics missing when enabled:\n%s", body)
+	}
+	if body := scrapeMetrics(setupTestMetricProvider(t, false)); strings.Contains(body, "go_goroutine") {
+		t.Errorf("runtime metrics exported when disabled:\n%s", body)
+	}
+}
+
+func TestPrometheusConstLabelsAndNamespace(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.PrometheusNamespace = "shop"
+	cfg.PrometheusConstLabels = map[string]string{"cluster": "eu-1", "region": "west"}
+
+	prev := otel.GetMeterProvider()
+	mp, err := SetupMetrics(cfg)
+	if err != nil {
+		t.Fatalf("SetupMetrics: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = mp.Shutdown(context.Background())
+		otel.SetMeterProvider(prev)
+	})
+	if got := mp.PrometheusNamespace(); got != "shop" {
+		t.Errorf("PrometheusNamespace() = %q, want shop", got)
+	}
+
+	counter, _ := mp.meterProvider.Meter("test").Int64Counter("orders")
+	counter.Add(context.Background(), 1)
+
+	body := scrapeMetrics(mp)
+	var series string
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "shop_orders_total{") {
+			series = line
+		}
+	}
+	if series == "" {
+		t.Fatalf("shop_orders_total not scraped:\n%s", body)
+	}
+	for _, label := range []string{`cluster="eu-1"`, `region="west"`} {
+		if !strings.Contains(series, label) {
+			t.Errorf("series %q missing label %s", series, label)
+		}
+	}
+}
@@ -2,73 +2,363 @@ package telemetry
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// defaultHTTPClientTimeout 追踪客户端的默认超时
+const defaultHTTPClientTimeout = 30 * time.Second
+
 // HTTPMiddleware 提供 HTTP 服务端和客户端的自动插桩
 type HTTPMiddleware struct {
-	tracer trace.Tracer
+	tracer            trace.Tracer
+	meter             metric.Meter
+	timeout           time.Duration
+	transport         http.RoundTripper
+	spanNameFormatter func(operation string, r *http.Request) string
+	// 指标中是否将状态码归类为 2xx/3xx/4xx/5xx
+	statusCodeBucketing bool
+	// 按路径配置的服务端采样比例（见 WithRouteSampling）
+	routeRatios map[string]float64
+}
+
+// HTTPOption 配置 HTTPMiddleware
+type HTTPOption func(*HTTPMiddleware)
+
+// WithHTTPTimeout 设置客户端超时（默认 30s，0 表示不超时）
+func WithHTTPTimeout(timeout time.Duration) HTTPOption {
+	return func(h *HTTPMiddleware) {
+		h.timeout = timeout
+	}
+}
+
+// WithHTTPTransport 设置客户端使用的底层 Transport（默认 http.DefaultTransport）
+func WithHTTPTransport(transport http.RoundTripper) HTTPOption {
+	return func(h *HTTPMiddleware) {
+		h.transport = transport
+	}
+}
+
+// WithHTTPSpanNameFormatter 设置服务端与客户端 span 的命名函数
+func WithHTTPSpanNameFormatter(formatter func(operation string, r *http.Request) string) HTTPOption {
+	return func(h *HTTPMiddleware) {
+		h.spanNameFormatter = formatter
+	}
+}
+
+// WithStatusCodeBucketing 设置客户端指标是否将状态码归类为 2xx、3xx、4xx、5xx（默认开启）
+// 开启时指标使用 http.response.status_class 属性以控制基数，关闭时使用精确的 http.response.status_code；
+// span 上始终记录精确状态码
+func WithStatusCodeBucketing(enabled bool) HTTPOption {
+	return func(h *HTTPMiddleware) {
+		h.statusCodeBucketing = enabled
+	}
 }
 
 // NewHTTPMiddleware 创建 HTTP 中间件
 func NewHTTPMiddleware(serviceName string) *HTTPMiddleware {
-	return &HTTPMiddleware{
-		tracer: otel.Tracer(serviceName),
+	return NewHTTPMiddlewareWithOptions(serviceName)
+}
+
+// NewHTTPMiddlewareWithOptions 创建可配置超时、Transport 与 span 命名的 HTTP 中间件
+func NewHTTPMiddlewareWithOptions(serviceName string, opts ...HTTPOption) *HTTPMiddleware {
+	h := &HTTPMiddleware{
+		tracer:              Tracer(serviceName),
+		meter:               Meter(serviceName),
+		timeout:             defaultHTTPClientTimeout,
+		transport:           http.DefaultTransport,
+		statusCodeBucketing: true,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// Handler 返回 HTTP 服务端中间件
-func (h *HTTPMiddleware) Handler(next http.Handler) http.Handler {
-	return otelhttp.NewHandler(next, "http-server",
+// otelhttpOptions 返回服务端与客户端共用的 otelhttp 配置
+func (h *HTTPMiddleware) otelhttpOptions() []otelhttp.Option {
+	opts := []otelhttp.Option{
 		otelhttp.WithTracerProvider(otel.GetTracerProvider()),
 		otelhttp.WithPropagators(otel.GetTextMapPropagator()),
-	)
+	}
+	if h.spanNameFormatter != nil {
+		opts = append(opts, otelhttp.WithSpanNameFormatter(h.spanNameFormatter))
+	}
+	return opts
+}
+
+// tracedTransport 使用 otelhttp 包装 Transport
+func (h *HTTPMiddleware) tracedTransport(transport http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(transport, h.otelhttpOptions()...)
+}
+
+// Handler 返回 HTTP 服务端中间件
+func (h *HTTPMiddleware) Handler(next http.Handler) http.Handler {
+	return h.routeSampling(otelhttp.NewHandler(h.recordPanics(next), "http-server", h.otelhttpOptions()...))
 }
 
 // HandlerWithName 返回指定名称的 HTTP 服务端中间件
 func (h *HTTPMiddleware) HandlerWithName(operationName string, next http.Handler) http.Handler {
-	return otelhttp.NewHandler(next, operationName,
-		otelhttp.WithTracerProvider(otel.GetTracerProvider()),
-		otelhttp.WithPropagators(otel.GetTextMapPropagator()),
-	)
+	return h.routeSampling(otelhttp.NewHandler(h.recordPanics(next), operationName, h.otelhttpOptions()...))
 }
 
-// Client 返回配置了追踪的 HTTP 客户端
+// Client 返回配置了追踪的 HTTP 客户端，超时默认为 30s
 func (h *HTTPMiddleware) Client() *http.Client {
+	return h.ClientWithTimeout(h.timeout)
+}
+
+// ClientWithTimeout 返回使用指定超时的追踪客户端
+func (h *HTTPMiddleware) ClientWithTimeout(timeout time.Duration) *http.Client {
 	return &http.Client{
-		Transport: otelhttp.NewTransport(http.DefaultTransport,
-			otelhttp.WithTracerProvider(otel.GetTracerProvider()),
-			otelhttp.WithPropagators(otel.GetTextMapPropagator()),
-		),
-		Timeout: 30 * time.Second,
+		Transport: h.tracedTransport(h.transport),
+		Timeout:   timeout,
 	}
 }
 
 // ClientWithTransport 返回使用指定 Transport 的追踪客户端
 func (h *HTTPMiddleware) ClientWithTransport(transport http.RoundTripper) *http.Client {
 	return &http.Client{
-		Transport: otelhttp.NewTransport(transport,
-			otelhttp.WithTracerProvider(otel.GetTracerProvider()),
-			otelhttp.WithPropagators(otel.GetTextMapPropagator()),
-		),
-		Timeout: 30 * time.Second,
+		Transport: h.tracedTransport(transport),
+		Timeout:   h.timeout,
+	}
+}
+
+// ClientWithPeerService 返回为客户端 span 设置 peer.service 属性的追踪客户端
+// name 通常为目标服务名，Jaeger 等后端据此构建服务依赖拓扑
+func (h *HTTPMiddleware) ClientWithPeerService(name string) *http.Client {
+	opts := append(h.otelhttpOptions(), otelhttp.WithSpanOptions(trace.WithAttributes(semconv.PeerService(name))))
+	return &http.Client{
+		Transport: otelhttp.NewTransport(h.transport, opts...),
+		Timeout:   h.timeout,
+	}
+}
+
+// ClientWithMetrics 返回同时记录追踪与客户端指标的 HTTP 客户端
+// 指标包括 http.client.request.duration 直方图与 http.client.requests 计数器，
+// 按方法、目标主机与状态码（默认归类为 2xx 等，见 WithStatusCodeBucketing）区分；传输错误（无响应）以 error=true 记录且不带状态码
+func (h *HTTPMiddleware) ClientWithMetrics() *http.Client {
+	duration, err := h.meter.Float64Histogram("http.client.request.duration",
+		metric.WithDescription("Duration of outbound HTTP requests"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	requests, err := h.meter.Int64Counter("http.client.requests",
+		metric.WithDescription("Number of outbound HTTP requests"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return &http.Client{
+		Transport: &metricsTransport{
+			next:                h.tracedTransport(h.transport),
+			duration:            duration,
+			requests:            requests,
+			statusCodeBucketing: h.statusCodeBucketing,
+		},
+		Timeout: h.timeout,
+	}
+}
+
+// metricsTransport 记录出站请求的耗时与计数
+type metricsTransport struct {
+	next                http.RoundTripper
+	duration            metric.Float64Histogram
+	requests            metric.Int64Counter
+	statusCodeBucketing bool
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Seconds()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("server.address", req.URL.Host),
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.Bool("error", true))
+	} else if t.statusCodeBucketing {
+		attrs = append(attrs, attribute.String("http.response.status_class", statusCodeClass(resp.StatusCode)))
+	} else {
+		attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+
+	opt := metric.WithAttributes(attrs...)
+	if t.duration != nil {
+		t.duration.Record(req.Context(), elapsed, opt)
+	}
+	if t.requests != nil {
+		t.requests.Add(req.Context(), 1, opt)
+	}
+
+	return resp, err
+}
+
+// statusCodeClass 将状态码归类为 1xx-5xx，超出范围的状态码归为 other
+func statusCodeClass(code int) string {
+	if code < 100 || code > 599 {
+		return "other"
+	}
+	return fmt.Sprintf("%dxx", code/100)
+}
+
+// ClientWithRetry 返回带重试的追踪客户端
+// 每次尝试（首次请求为 http.retry.0）都在名为 http.retry.N 的子 span 中执行，记录尝试序号与上一次错误，
+// 调用方的 span 记录总尝试次数；默认仅重试幂等方法，退避间隔按 backoff 指数增长（上限 30s），并尊重上下文取消
+func (h *HTTPMiddleware) ClientWithRetry(maxRetries int, backoff time.Duration) *http.Client {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return &http.Client{
+		Transport: &retryTransport{
+			next:       h.tracedTransport(h.transport),
+			tracer:     h.tracer,
+			maxRetries: maxRetries,
+			backoff:    backoff,
+		},
+		Timeout: h.timeout,
 	}
 }
 
+// retryTransport 在底层 Transport 之上实现带追踪的重试
+type retryTransport struct {
+	next       http.RoundTripper
+	tracer     trace.Tracer
+	maxRetries int
+	backoff    time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// 非幂等方法或无法重放的请求体不重试
+	maxRetries := t.maxRetries
+	if !isIdempotentMethod(req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		maxRetries = 0
+	}
+
+	var (
+		resp     *http.Response
+		err      error
+		prevErr  error
+		attempts int
+	)
+	for attempt := 0; ; attempt++ {
+		attempts++
+		resp, err = t.attempt(ctx, req, attempt, prevErr)
+		if (err == nil && resp.StatusCode < http.StatusInternalServerError) || attempt == maxRetries {
+			break
+		}
+
+		// 记录本次失败原因并释放响应，准备重试
+		if err != nil {
+			prevErr = err
+		} else {
+			prevErr = fmt.Errorf("server responded with status %d", resp.StatusCode)
+			_ = resp.Body.Close()
+		}
+
+		// 退避等待，期间响应取消
+		if err = waitBackoff(ctx, retryBackoff(t.backoff, attempt+1)); err != nil {
+			resp = nil
+			break
+		}
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("http.retry.total_attempts", attempts))
+	return resp, err
+}
+
+// maxRetryBackoff 单次退避等待的上限
+const maxRetryBackoff = 30 * time.Second
+
+// retryBackoff 返回第 retry 次重试（从 1 开始）前的退避间隔：backoff 每次翻倍，不超过 maxRetryBackoff
+func retryBackoff(backoff time.Duration, retry int) time.Duration {
+	wait := backoff
+	for i := 1; i < retry && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRetryBackoff)
+}
+
+// waitBackoff 等待退避间隔，上下文取消时提前返回其错误
+func waitBackoff(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// attempt 在 http.retry.N 子 span 中执行一次请求，N 为尝试序号（首次请求为 0）
+func (t *retryTransport) attempt(ctx context.Context, req *http.Request, attempt int, prevErr error) (*http.Response, error) {
+	attrs := []attribute.KeyValue{attribute.Int("http.retry.attempt", attempt)}
+	if prevErr != nil {
+		attrs = append(attrs, attribute.String("http.retry.previous_error", prevErr.Error()))
+	}
+	ctx, span := t.tracer.Start(ctx, fmt.Sprintf("http.retry.%d", attempt), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	attemptReq := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
+
+	resp, err := t.next.RoundTrip(attemptReq)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, err
+}
+
+// isIdempotentMethod 判断 HTTP 方法是否幂等
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
 // WrapHandler 包装 HTTP 处理器，添加自定义属性
+// 请求经 http.ServeMux 路由时，以匹配的模式（r.Pattern）设置 http.route 属性，并将 span 名称设为 "{method} {route}"，
+// 避免以原始 URL 命名带来的高基数；未匹配到模式时 span 名称保持为 operationName
 func (h *HTTPMiddleware) WrapHandler(operationName string, handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, span := h.tracer.Start(r.Context(), operationName)
+		ctx, span := h.tracer.Start(r.Context(), operationName, trace.WithSpanKind(trace.SpanKindServer))
 		defer span.End()
 
+		// 处理器注册在 ServeMux 上时模式在进入前已确定
+		setHTTPRoute(span, r.Method, r.Pattern)
+
 		// 添加请求属性
 		span.SetAttributes(
 			attribute.String("http.method", r.Method),
@@ -82,7 +372,13 @@ func (h *HTTPMiddleware) WrapHandler(operationName string, handler http.HandlerF
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 		
 		// 执行处理器
-		handler(wrapped, r.WithContext(ctx))
+		req := r.WithContext(ctx)
+		handler(wrapped, req)
+
+		// 包装的是 ServeMux 本身时，模式在路由后才写入请求
+		if r.Pattern == "" {
+			setHTTPRoute(span, r.Method, req.Pattern)
+		}
 
 		// 设置响应属性
 		span.SetAttributes(attribute.Int("http.status_code", wrapped.statusCode))
@@ -94,6 +390,22 @@ func (h *HTTPMiddleware) WrapHandler(operationName string, handler http.HandlerF
 	}
 }
 
+// setHTTPRoute 由 ServeMux 模式（形如 "[METHOD ][HOST]/PATH"）设置 http.route 属性与 span 名称，模式为空时不做处理
+func setHTTPRoute(span trace.Span, method, pattern string) {
+	if pattern == "" {
+		return
+	}
+	route := pattern
+	if i := strings.IndexByte(route, ' '); i >= 0 {
+		route = strings.TrimLeft(route[i+1:], " \t")
+	}
+	if i := strings.IndexByte(route, '/'); i > 0 {
+		route = route[i:]
+	}
+	span.SetAttributes(semconv.HTTPRoute(route))
+	span.SetName(method + " " + route)
+}
+
 // responseWriter 包装 http.ResponseWriter 以捕获状态码
 type responseWriter struct {
 	http.ResponseWriter
@@ -0,0 +1,254 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestClientWithRetry(t *testing.T) {
+	exporter := setupTestTracing(t)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPMiddleware("retry-test").ClientWithRetry(3, time.Millisecond)
+	ctx, parent := Tracer("retry-test").Start(context.Background(), "caller")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	parent.End()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200 after retries", resp.StatusCode)
+	}
+	spans := exporter.GetSpans()
+	for i, name := range []string{"http.retry.0", "http.retry.1", "http.retry.2"} {
+		span, ok := findSpan(spans, name)
+		if !ok {
+			t.Errorf("missing attempt span %s", name)
+			continue
+		}
+		_, hasPrev := attrValue(span.Attributes, "http.retry.previous_error")
+		if hasPrev != (i > 0) {
+			t.Errorf("%s has previous_error = %v, want %v", name, hasPrev, i > 0)
+		}
+	}
+	caller, _ := findSpan(spans, "caller")
+	if v, _ := attrValue(caller.Attributes, "http.retry.total_attempts"); v != "3" {
+		t.Errorf("total_attempts = %q, want 3", v)
+	}
+}
+
+func TestClientWithRetryCancelled(t *testing.T) {
+	exporter := setupTestTracing(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewHTTPMiddleware("retry-test").ClientWithRetry(5, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ctx, parent := Tracer("retry-test").Start(ctx, "caller")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded during backoff", err)
+	}
+	parent.End()
+
+	caller, _ := findSpan(exporter.GetSpans(), "caller")
+	if v, _ := attrValue(caller.Attributes, "http.retry.total_attempts"); v != "1" {
+		t.Errorf("total_attempts = %q, want 1", v)
+	}
+}
+
+func TestRetryBackoffIsCapped(t *testing.T) {
+	if got := retryBackoff(100*time.Millisecond, 3); got != 400*time.Millisecond {
+		t.Errorf("retryBackoff(100ms, 3) = %v, want 400ms", got)
+	}
+	// 重试次数很大时退避间隔不溢出为 0 或负数
+	for _, retry := range []int{40, 64, 1000} {
+		if got := retryBackoff(time.Second, retry); got != maxRetryBackoff {
+			t.Errorf("retryBackoff(1s, %d) = %v, want %v", retry, got, maxRetryBackoff)
+		}
+	}
+}
+
+func TestHTTPClientTimeout(t *testing.T) {
+	if got := NewHTTPMiddleware("timeout-test").Client().Timeout; got != defaultHTTPClientTimeout {
+		t.Errorf("Client().Timeout = %v, want %v", got, defaultHTTPClientTimeout)
+	}
+
+	mw := NewHTTPMiddlewareWithOptions("timeout-test", WithHTTPTimeout(5*time.Second))
+	for name, client := range map[string]*http.Client{
+		"Client":              mw.Client(),
+		"ClientWithTransport": mw.ClientWithTransport(http.DefaultTransport),
+		"ClientWithMetrics":   mw.ClientWithMetrics(),
+		"ClientWithRetry":     mw.ClientWithRetry(1, time.Millisecond),
+	} {
+		if client.Timeout != 5*time.Second {
+			t.Errorf("%s().Timeout = %v, want 5s", name, client.Timeout)
+		}
+	}
+	if got := mw.ClientWithTimeout(time.Second).Timeout; got != time.Second {
+		t.Errorf("ClientWithTimeout(1s).Timeout = %v, want 1s", got)
+	}
+
+	// 超时对实际请求生效
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	start := time.Now()
+	_, err := mw.ClientWithTimeout(50 * time.Millisecond).Get(server.URL)
+	if err == nil {
+		t.Fatal("request to a hanging server succeeded, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("request took %v, want it to time out after 50ms", elapsed)
+	}
+}
+
+// requestStatusAttr 发出一个返回 204 的请求，返回 http.client.requests 数据点上 key 属性的值
+func requestStatusAttr(t *testing.T, bucketing bool, key attribute.Key) (attribute.Value, bool) {
+	t.Helper()
+	reader := setupTestMetrics(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewHTTPMiddlewareWithOptions("bucketing-test", WithStatusCodeBucketing(bucketing)).ClientWithMetrics()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	_, m, ok := collectMetric(t, reader, "http.client.requests")
+	if !ok {
+		t.Fatal("http.client.requests not collected")
+	}
+	points := m.Data.(metricdata.Sum[int64]).DataPoints
+	if len(points) != 1 {
+		t.Fatalf("got %d data points, want 1", len(points))
+	}
+	return points[0].Attributes.Value(key)
+}
+
+func TestStatusCodeBucketing(t *testing.T) {
+	exporter := setupTestTracing(t)
+	if v, ok := requestStatusAttr(t, true, "http.response.status_class"); !ok || v.AsString() != "2xx" {
+		t.Errorf("status_class = %q, want 2xx for a 204", v.AsString())
+	}
+	// span 上始终记录精确状态码（otelhttp 默认使用旧版 semconv 的 http.status_code）
+	if len(exporter.GetSpans()) == 0 {
+		t.Fatal("no client span exported")
+	}
+	if v, _ := attrValue(exporter.GetSpans()[0].Attributes, "http.status_code"); v != "204" {
+		t.Errorf("span status_code = %q, want 204", v)
+	}
+
+	if v, ok := requestStatusAttr(t, false, "http.response.status_code"); !ok || v.AsInt64() != http.StatusNoContent {
+		t.Errorf("status_code = %v, want 204 with bucketing disabled", v.Emit())
+	}
+}
+
+func TestStatusCodeClass(t *testing.T) {
+	for code, want := range map[int]string{101: "1xx", 204: "2xx", 302: "3xx", 404: "4xx", 503: "5xx", 99: "other", 600: "other"} {
+		if got := statusCodeClass(code); got != want {
+			t.Errorf("statusCodeClass(%d) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestWrapHandlerRoutePattern(t *testing.T) {
+	exporter := setupTestTracing(t)
+	h := NewHTTPMiddleware("route-test")
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	// 处理器注册在 ServeMux 上
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", h.WrapHandler("get-user", ok))
+	mux.HandleFunc("/health", h.WrapHandler("health", ok))
+	// 包装 ServeMux 本身
+	inner := http.NewServeMux()
+	inner.HandleFunc("POST example.com/orders/{id}/items", ok)
+	wrappedMux := h.WrapHandler("orders", inner.ServeHTTP)
+
+	tests := []struct {
+		handler  http.Handler
+		method   string
+		target   string
+		wantName string
+		route    string
+	}{
+		{mux, http.MethodGet, "/users/42", "GET /users/{id}", "/users/{id}"},
+		{mux, http.MethodGet, "/health", "GET /health", "/health"},
+		{wrappedMux, http.MethodPost, "http://example.com/orders/7/items", "POST /orders/{id}/items", "/orders/{id}/items"},
+		{wrappedMux, http.MethodGet, "/unknown", "orders", ""},
+	}
+	for _, tt := range tests {
+		exporter.Reset()
+		tt.handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.target, nil))
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("%s %s: got %d spans, want 1", tt.method, tt.target, len(spans))
+		}
+		if spans[0].Name != tt.wantName {
+			t.Errorf("%s %s: span name = %q, want %q", tt.method, tt.target, spans[0].Name, tt.wantName)
+		}
+		route, hasRoute := attrValue(spans[0].Attributes, "http.route")
+		if route != tt.route || hasRoute != (tt.route != "") {
+			t.Errorf("%s %s: http.route = %q (set %v), want %q", tt.method, tt.target, route, hasRoute, tt.route)
+		}
+	}
+}
+
+func TestHTTPSpanKinds(t *testing.T) {
+	exporter := setupTestTracing(t)
+	h := NewHTTPMiddleware("kind-test")
+
+	mux := http.NewServeMux()
+	mux.Handle("/otel", h.HandlerWithName("otel-server", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	mux.HandleFunc("/wrapped", h.WrapHandler("wrapped-server", func(w http.ResponseWriter, r *http.Request) {}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, path := range []string{"/otel", "/wrapped"} {
+		resp, err := h.Client().Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	kinds := map[trace.SpanKind]int{}
+	for _, span := range exporter.GetSpans() {
+		kinds[span.SpanKind]++
+	}
+	// 每个请求各产生一个客户端 span 与一个服务端 span（WrapHandler 的 span 以路由模式命名）
+	if kinds[trace.SpanKindServer] != 2 || kinds[trace.SpanKindClient] != 2 || len(kinds) != 2 {
+		t.Errorf("span kinds = %v, want 2 server and 2 client spans", kinds)
+	}
+	if span, ok := findSpan(exporter.GetSpans(), "GET /wrapped"); !ok || span.SpanKind != trace.SpanKindServer {
+		t.Errorf("WrapHandler span kind = %s (exported %v), want server", span.SpanKind, ok)
+	}
+}
@@ -0,0 +1,106 @@
+package telemetry
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// semconvNamespaces 语义约定使用的属性键前缀，带这些前缀的键不添加应用命名空间
+var semconvNamespaces = []string{
+	"client.", "cloud.", "code.", "container.", "db.", "deployment.", "enduser.", "error.",
+	"exception.", "faas.", "host.", "http.", "k8s.", "messaging.", "net.", "network.",
+	"os.", "otel.", "peer.", "process.", "rpc.", "server.", "service.", "telemetry.",
+	"thread.", "url.", "user_agent.",
+}
+
+// attributeNamespace 当前生效的应用属性命名空间
+var attributeNamespace atomic.Value
+
+func init() {
+	attributeNamespace.Store("")
+}
+
+// SetAttributeNamespace 设置 NamespacedAttrs 使用的应用属性命名空间
+func SetAttributeNamespace(namespace string) {
+	attributeNamespace.Store(namespace)
+}
+
+// namespacedKey 为非语义约定的键添加命名空间前缀，已带前缀的键保持不变
+func namespacedKey(namespace string, key attribute.Key) attribute.Key {
+	k := string(key)
+	if namespace == "" || strings.HasPrefix(k, namespace+".") {
+		return key
+	}
+	for _, prefix := range semconvNamespaces {
+		if strings.HasPrefix(k, prefix) {
+			return key
+		}
+	}
+	return attribute.Key(namespace + "." + k)
+}
+
+// NamespacedAttrs 为非语义约定的属性键添加 Config.AttributeNamespace 前缀（如 data.id -> acme.data.id）
+// NamespaceSpanProcessor 只处理 span 属性，指标、日志以及 span 事件的属性需在调用处使用本函数
+func NamespacedAttrs(attrs ...attribute.KeyValue) []attribute.KeyValue {
+	namespace := attributeNamespace.Load().(string)
+	if namespace == "" {
+		return attrs
+	}
+	out := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		out[i] = attribute.KeyValue{Key: namespacedKey(namespace, attr.Key), Value: attr.Value}
+	}
+	return out
+}
+
+// NamespaceSpanProcessor 在 span 结束、交给下游处理器导出之前为非语义约定的属性键添加命名空间前缀
+// 在结束时改写，因此也覆盖 span 开始后才设置的属性
+type NamespaceSpanProcessor struct {
+	namespace string
+	next      sdktrace.SpanProcessor
+}
+
+// NewNamespaceSpanProcessor 创建命名空间处理器，next 通常为批处理器
+func NewNamespaceSpanProcessor(namespace string, next sdktrace.SpanProcessor) *NamespaceSpanProcessor {
+	return &NamespaceSpanProcessor{namespace: namespace, next: next}
+}
+
+// OnStart 转发给下游处理器
+func (p *NamespaceSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd 改写属性键后转发给下游处理器
+func (p *NamespaceSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.next.OnEnd(&namespacedSpan{ReadOnlySpan: s, namespace: p.namespace})
+}
+
+// Shutdown 关闭下游处理器
+func (p *NamespaceSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush 刷新下游处理器
+func (p *NamespaceSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// namespacedSpan 返回带命名空间属性键的只读 span
+type namespacedSpan struct {
+	sdktrace.ReadOnlySpan
+	namespace string
+}
+
+// Attributes 返回改写后的属性
+func (s *namespacedSpan) Attributes() []attribute.KeyValue {
+	attrs := s.ReadOnlySpan.Attributes()
+	out := make([]attribute.KeyValue, len(attrs))
+	for i, attr := range attrs {
+		out[i] = attribute.KeyValue{Key: namespacedKey(s.namespace, attr.Key), Value: attr.Value}
+	}
+	return out
+}
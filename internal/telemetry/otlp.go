@@ -0,0 +1,372 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// OTLP 传输协议，取值与 OTEL_EXPORTER_OTLP_PROTOCOL 一致
+const (
+	otlpProtocolGRPC = "grpc"
+	otlpProtocolHTTP = "http/protobuf"
+)
+
+// otlpEndpoint 解析后的 OTLP 端点
+type otlpEndpoint struct {
+	// 拨号目标（host:port）
+	target string
+	// 端点 scheme 为 https 时要求启用 TLS
+	tls bool
+	// 传输协议（otlpProtocolGRPC 或 otlpProtocolHTTP）
+	protocol string
+	// HTTP 协议下各信号路径的前缀（如 /otlp），gRPC 协议下不使用
+	path string
+}
+
+// parseOTLPEndpoint 解析 OTLP 端点，支持裸 host:port 与带 scheme/path 的完整 URL
+// https:// 推断为启用 TLS；protocol 为空时按端点推断协议：带 scheme 的 URL 使用 HTTP（http/protobuf），裸 host:port 使用 gRPC；
+// 使用 gRPC 时 scheme 与 path 会被去除后再拨号
+func parseOTLPEndpoint(endpoint, protocol string) (otlpEndpoint, error) {
+	switch protocol {
+	case "", otlpProtocolGRPC, otlpProtocolHTTP:
+	default:
+		return otlpEndpoint{}, fmt.Errorf("unsupported OTLP protocol %q (want %q or %q)", protocol, otlpProtocolGRPC, otlpProtocolHTTP)
+	}
+
+	if !strings.Contains(endpoint, "://") {
+		if protocol == "" {
+			protocol = otlpProtocolGRPC
+		}
+		return otlpEndpoint{target: endpoint, protocol: protocol}, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return otlpEndpoint{}, fmt.Errorf("invalid OTLP endpoint %q: %w", endpoint, err)
+	}
+	if u.Host == "" {
+		return otlpEndpoint{}, fmt.Errorf("invalid OTLP endpoint %q: missing host", endpoint)
+	}
+	if protocol == "" {
+		protocol = otlpProtocolHTTP
+	}
+	parsed := otlpEndpoint{target: u.Host, protocol: protocol, path: strings.TrimSuffix(u.Path, "/")}
+
+	switch u.Scheme {
+	case "http":
+		return parsed, nil
+	case "https":
+		parsed.tls = true
+		return parsed, nil
+	default:
+		return otlpEndpoint{}, fmt.Errorf("unsupported OTLP endpoint scheme %q", u.Scheme)
+	}
+}
+
+// signalPath 返回 HTTP 协议下信号（traces/metrics）的 URL 路径，如 /v1/traces
+func (e otlpEndpoint) signalPath(signal string) string {
+	return e.path + "/v1/" + signal
+}
+
+// defaultOTLPDialTimeout 未设置 OTLPDialTimeout 时的拨号超时
+const defaultOTLPDialTimeout = 5 * time.Second
+
+// otlpDialTimeout 返回拨号超时，未设置（0）时使用默认值，避免以已过期的上下文拨号
+func otlpDialTimeout(cfg Config) time.Duration {
+	if cfg.OTLPDialTimeout <= 0 {
+		return defaultOTLPDialTimeout
+	}
+	return cfg.OTLPDialTimeout
+}
+
+// otlpConnections 已建立的 OTLP 连接，按信号类型（traces/metrics）索引，用于连接状态指标
+var otlpConnections sync.Map
+
+// dialOTLP 根据配置建立到 OTLP 端点的 gRPC 连接
+// 连接断开（如 collector 重启）后按退避策略自动重连，signal 用于标识连接状态指标
+func dialOTLP(cfg Config, signal string) (*grpc.ClientConn, error) {
+	endpoint, err := parseOTLPEndpoint(cfg.OTLPEndpoint, cfg.OTLPProtocol)
+	if err != nil {
+		return nil, err
+	}
+
+	grpcOpts, err := otlpDialOptions(cfg, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), otlpDialTimeout(cfg))
+	defer cancel()
+
+	grpcOpts = append(grpcOpts, grpc.WithBlock())
+
+	conn, err := grpc.DialContext(ctx, endpoint.target, grpcOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to OTLP endpoint: %w", err)
+	}
+
+	otlpConnections.Store(signal, conn)
+	go watchOTLPConnection(signal, conn)
+	return conn, nil
+}
+
+// CheckOTLPEndpoint 检查 OTLP collector 是否可达，用于启动时快速失败并给出明确的错误信息
+// 在 OTLPDialTimeout 内完成拨号后调用 collector 的 gRPC 健康检查服务；collector 未注册健康检查服务时仅以拨号结果为准
+// 使用 HTTP 协议时只检查 TCP 连接能否建立；未配置 OTLPEndpoint 时直接返回 nil
+func CheckOTLPEndpoint(ctx context.Context, cfg Config) error {
+	if cfg.OTLPEndpoint == "" {
+		return nil
+	}
+	endpoint, err := parseOTLPEndpoint(cfg.OTLPEndpoint, cfg.OTLPProtocol)
+	if err != nil {
+		return err
+	}
+
+	timeout := otlpDialTimeout(cfg)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if endpoint.protocol == otlpProtocolHTTP {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", endpoint.target)
+		if err != nil {
+			return fmt.Errorf("OTLP collector unreachable at %s within %s: %w", endpoint.target, timeout, err)
+		}
+		return conn.Close()
+	}
+
+	grpcOpts, err := otlpDialOptions(cfg, endpoint)
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.DialContext(ctx, endpoint.target, append(grpcOpts, grpc.WithBlock())...)
+	if err != nil {
+		return fmt.Errorf("OTLP collector unreachable at %s within %s: %w", endpoint.target, timeout, err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+		return fmt.Errorf("OTLP collector health check failed at %s: %w", endpoint.target, err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("OTLP collector at %s is not serving (status %s)", endpoint.target, resp.GetStatus())
+	}
+	return nil
+}
+
+// otlpDialOptions 根据配置构造 OTLP gRPC 连接选项（TLS、keepalive、消息大小、认证与重连退避）
+func otlpDialOptions(cfg Config, endpoint otlpEndpoint) ([]grpc.DialOption, error) {
+	// 配置 gRPC 连接选项
+	var grpcOpts []grpc.DialOption
+
+	// 配置 TLS 凭据（显式启用或端点 scheme 为 https）
+	if cfg.TLSConfig.Enabled || endpoint.tls {
+		tlsConfig, err := createTLSConfig(cfg.TLSConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	// 配置 keepalive，避免长连接被中间代理断开
+	if cfg.OTLPKeepalive > 0 {
+		grpcOpts = append(grpcOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.OTLPKeepalive,
+			Timeout:             cfg.OTLPKeepalive / 2,
+			PermitWithoutStream: true,
+		}))
+	}
+
+	// 配置消息大小上限，避免大批量导出失败
+	if cfg.OTLPMaxMessageSize > 0 {
+		grpcOpts = append(grpcOpts, grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(cfg.OTLPMaxMessageSize),
+			grpc.MaxCallRecvMsgSize(cfg.OTLPMaxMessageSize),
+		))
+	}
+
+	// 配置 bearer token 认证，每次导出前获取最新 token
+	if cfg.OTLPTokenSource != nil {
+		secure := cfg.TLSConfig.Enabled || endpoint.tls
+		if !secure {
+			zap.L().Warn("OTLP bearer token is sent over an insecure connection",
+				zap.String("endpoint", endpoint.target),
+			)
+		}
+		grpcOpts = append(grpcOpts, grpc.WithPerRPCCredentials(&bearerTokenCredentials{
+			source: cfg.OTLPTokenSource,
+			secure: secure,
+		}))
+	}
+
+	// 在导出器之前处理 partial success 响应
+	grpcOpts = append(grpcOpts, grpc.WithChainUnaryInterceptor(partialSuccessInterceptor))
+
+	// 配置重连退避，WithBlock 只作用于首次拨号，之后的断线由 gRPC 按该策略重连
+	grpcOpts = append(grpcOpts, grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff:           otlpBackoff(cfg.RetryConfig),
+		MinConnectTimeout: otlpDialTimeout(cfg),
+	}))
+
+	return grpcOpts, nil
+}
+
+// bearerTokenCredentials 为每次 RPC 附加 bearer token 的 gRPC 凭据
+type bearerTokenCredentials struct {
+	source func() (string, error)
+	secure bool
+}
+
+// GetRequestMetadata 获取最新 token 并设置 authorization 头
+func (c *bearerTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	token, err := c.source()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OTLP bearer token: %w", err)
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+// RequireTransportSecurity 仅在 TLS 连接上要求传输安全，明文连接在拨号时已给出警告
+func (c *bearerTokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
+
+// otlpBackoff 由重试配置构造 gRPC 重连退避策略，未配置的字段使用 gRPC 默认值
+func otlpBackoff(retry RetryConfig) backoff.Config {
+	cfg := backoff.DefaultConfig
+	if retry.InitialInterval > 0 {
+		cfg.BaseDelay = retry.InitialInterval
+	}
+	if retry.MaxInterval > 0 {
+		cfg.MaxDelay = retry.MaxInterval
+	}
+	if retry.Multiplier > 0 {
+		cfg.Multiplier = retry.Multiplier
+	}
+	if retry.RandomizationFactor > 0 {
+		cfg.Jitter = retry.RandomizationFactor
+	}
+	return cfg
+}
+
+// watchOTLPConnection 监视连接状态，连接进入 Idle 时主动重连，
+// 避免在两次导出之间长期处于空闲状态导致连接状态指标失真；连接关闭后退出
+func watchOTLPConnection(signal string, conn *grpc.ClientConn) {
+	state := conn.GetState()
+	for state != connectivity.Shutdown {
+		if state == connectivity.Idle {
+			conn.Connect()
+		}
+		conn.WaitForStateChange(context.Background(), state)
+		state = conn.GetState()
+	}
+	otlpConnections.CompareAndDelete(signal, conn)
+}
+
+// registerOTLPConnectionGauge 注册 telemetry_otlp_connection_up 指标，连接就绪时为 1，否则为 0
+func registerOTLPConnectionGauge(meter metric.Meter) {
+	_, _ = meter.Int64ObservableGauge("telemetry_otlp_connection_up",
+		metric.WithDescription("OTLP gRPC connection state (1=ready)"),
+		metric.WithUnit("{state}"),
+		metric.WithInt64Callback(func(ctx context.Context, o metric.Int64Observer) error {
+			otlpConnections.Range(func(key, value any) bool {
+				var up int64
+				if value.(*grpc.ClientConn).GetState() == connectivity.Ready {
+					up = 1
+				}
+				o.Observe(up, metric.WithAttributes(attribute.String("signal", key.(string))))
+				return true
+			})
+			return nil
+		}),
+	)
+}
+
+// otlpRejected telemetry_otlp_rejected_total 计数器，由 registerOTLPRejectedCounter 在当前 Provider 的 meter 上创建
+var otlpRejected atomic.Pointer[metric.Int64Counter]
+
+// registerOTLPRejectedCounter 创建 telemetry_otlp_rejected_total，替换此前（如重建前的 provider 上）创建的计数器
+func registerOTLPRejectedCounter(meter metric.Meter) {
+	rejected, err := meter.Int64Counter("telemetry_otlp_rejected_total",
+		metric.WithDescription("Number of items rejected by the OTLP endpoint in partial success responses"),
+		metric.WithUnit("{item}"),
+	)
+	if err != nil {
+		Logger().Warn("Failed to create OTLP rejected counter", zap.Error(err))
+		return
+	}
+	otlpRejected.Store(&rejected)
+}
+
+// installOTelErrorHandler 将 OpenTelemetry SDK 内部错误输出到 zap 日志
+// OTLP partial success 已由 partialSuccessInterceptor 处理并从响应中移除，不会再经由此处报告
+func installOTelErrorHandler() {
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		Logger().Error("OpenTelemetry error", zap.Error(err))
+	}))
+}
+
+// partialSuccessInterceptor 在 OTLP 导出响应交给导出器之前读取其中的 partial success（collector 拒绝了部分 span/数据点），
+// 记录警告并按信号计入 telemetry_otlp_rejected_total，然后从响应中移除，避免导出器再以通用错误重复报告
+func partialSuccessInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+		return err
+	}
+	switch resp := reply.(type) {
+	case *coltracepb.ExportTraceServiceResponse:
+		if ps := resp.GetPartialSuccess(); ps != nil {
+			reportPartialSuccess("traces", ps.GetRejectedSpans(), ps.GetErrorMessage())
+			resp.PartialSuccess = nil
+		}
+	case *colmetricpb.ExportMetricsServiceResponse:
+		if ps := resp.GetPartialSuccess(); ps != nil {
+			reportPartialSuccess("metrics", ps.GetRejectedDataPoints(), ps.GetErrorMessage())
+			resp.PartialSuccess = nil
+		}
+	}
+	return nil
+}
+
+// reportPartialSuccess 记录被拒绝的数据量，与导出器一致，rejected 为 0 且没有消息时视为完全成功
+func reportPartialSuccess(signal string, rejected int64, message string) {
+	if rejected == 0 && message == "" {
+		return
+	}
+	Logger().Warn("OTLP endpoint rejected part of an export",
+		zap.String("signal", signal),
+		zap.Int64("rejected", rejected),
+		zap.String("message", message),
+	)
+	if counter := otlpRejected.Load(); counter != nil {
+		(*counter).Add(context.Background(), rejected, metric.WithAttributes(attribute.String("signal", signal)))
+	}
+}
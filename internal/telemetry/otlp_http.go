@@ -0,0 +1,104 @@
+package telemetry
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// checkOTLPHTTPConfig 检查 HTTP 协议不支持的配置
+// HTTP 导出器只能设置固定的请求头，无法在每次导出前刷新 token，因此 OTLPTokenSource 需使用 gRPC 协议
+func checkOTLPHTTPConfig(cfg Config) error {
+	if cfg.OTLPTokenSource != nil {
+		return fmt.Errorf("OTLPTokenSource requires the %q OTLP protocol", otlpProtocolGRPC)
+	}
+	return nil
+}
+
+// newOTLPTraceHTTPClient 创建以 HTTP（protobuf）导出 span 的 OTLP 客户端，请求发往 {path}/v1/traces
+func newOTLPTraceHTTPClient(cfg Config, endpoint otlpEndpoint) (otlptrace.Client, error) {
+	if err := checkOTLPHTTPConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(endpoint.target),
+		otlptracehttp.WithURLPath(endpoint.signalPath("traces")),
+	}
+
+	// 配置 TLS（显式启用或端点 scheme 为 https）
+	if cfg.TLSConfig.Enabled || endpoint.tls {
+		tlsConfig, err := createTLSConfig(cfg.TLSConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+	} else {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	// 配置单次导出超时
+	if cfg.OTLPExportTimeout > 0 {
+		opts = append(opts, otlptracehttp.WithTimeout(cfg.OTLPExportTimeout))
+	}
+
+	// 配置重试选项
+	if cfg.RetryConfig.Enabled {
+		opts = append(opts, otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: cfg.RetryConfig.InitialInterval,
+			MaxInterval:     cfg.RetryConfig.MaxInterval,
+			MaxElapsedTime:  cfg.RetryConfig.MaxElapsedTime,
+		}))
+	}
+
+	return otlptracehttp.NewClient(opts...), nil
+}
+
+// newOTLPMetricHTTPOptions 返回以 HTTP（protobuf）导出指标的选项，请求发往 {path}/v1/metrics
+func newOTLPMetricHTTPOptions(cfg Config, endpoint otlpEndpoint, temporality metric.TemporalitySelector) ([]otlpmetrichttp.Option, error) {
+	if err := checkOTLPHTTPConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	opts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(endpoint.target),
+		otlpmetrichttp.WithURLPath(endpoint.signalPath("metrics")),
+	}
+
+	// 配置 TLS（显式启用或端点 scheme 为 https）
+	if cfg.TLSConfig.Enabled || endpoint.tls {
+		tlsConfig, err := createTLSConfig(cfg.TLSConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsConfig))
+	} else {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	}
+
+	// 配置单次导出超时
+	if cfg.OTLPExportTimeout > 0 {
+		opts = append(opts, otlpmetrichttp.WithTimeout(cfg.OTLPExportTimeout))
+	}
+
+	// 配置聚合时间性
+	if temporality != nil {
+		opts = append(opts, otlpmetrichttp.WithTemporalitySelector(temporality))
+	}
+
+	// 配置重试选项
+	if cfg.RetryConfig.Enabled {
+		opts = append(opts, otlpmetrichttp.WithRetry(otlpmetrichttp.RetryConfig{
+			Enabled:         true,
+			InitialInterval: cfg.RetryConfig.InitialInterval,
+			MaxInterval:     cfg.RetryConfig.MaxInterval,
+			MaxElapsedTime:  cfg.RetryConfig.MaxElapsedTime,
+		}))
+	}
+
+	return opts, nil
+}
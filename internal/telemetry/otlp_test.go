@@ -0,0 +1,365 @@
+package telemetry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakeCollector 统计收到的 span 数的 OTLP trace 服务
+type fakeCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	server *grpc.Server
+
+	mu    sync.Mutex
+	spans int
+	// 非 nil 时在每个响应中返回该 partial success
+	partial *coltracepb.ExportTracePartialSuccess
+}
+
+// startFakeCollector 在 addr（如 127.0.0.1:0）上启动 collector，返回实际监听地址，测试结束时停止
+func startFakeCollector(t *testing.T, addr string) (*fakeCollector, string) {
+	t.Helper()
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	c := &fakeCollector{server: grpc.NewServer()}
+	coltracepb.RegisterTraceServiceServer(c.server, c)
+	go c.server.Serve(lis)
+	t.Cleanup(c.server.Stop)
+	return c, lis.Addr().String()
+}
+
+func (c *fakeCollector) Export(_ context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans += len(ss.Spans)
+		}
+	}
+	return &coltracepb.ExportTraceServiceResponse{PartialSuccess: c.partial}, nil
+}
+
+// received 返回已收到的 span 数
+func (c *fakeCollector) received() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.spans
+}
+
+// otlpTestConfig 返回指向 endpoint 且重连退避较短的配置
+func otlpTestConfig(endpoint string) Config {
+	cfg := testProviderConfig()
+	cfg.OTLPEndpoint = endpoint
+	cfg.OTLPDialTimeout = 2 * time.Second
+	cfg.OTLPExportTimeout = time.Second
+	cfg.RetryConfig = RetryConfig{InitialInterval: 10 * time.Millisecond, MaxInterval: 100 * time.Millisecond}
+	return cfg
+}
+
+// setupTestOTLPTracing 创建导出到 OTLP 端点的 TraceProvider，测试结束时恢复全局 tracer provider
+func setupTestOTLPTracing(t *testing.T, cfg Config) *TraceProvider {
+	t.Helper()
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	tp, err := setupTracing(cfg, metricnoop.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("setupTracing: %v", err)
+	}
+	return tp
+}
+
+// exportTestSpan 结束一个 span 并立即刷新批处理器
+func exportTestSpan(tp *TraceProvider) {
+	_, span := tp.provider.Tracer("test").Start(context.Background(), "op")
+	span.End()
+	_ = tp.provider.ForceFlush(context.Background())
+}
+
+func TestOTLPReconnectsAfterCollectorRestart(t *testing.T) {
+	collector, addr := startFakeCollector(t, "127.0.0.1:0")
+	tp := setupTestOTLPTracing(t, otlpTestConfig(addr))
+
+	exportTestSpan(tp)
+	if got := collector.received(); got != 1 {
+		t.Fatalf("collector received %d spans, want 1", got)
+	}
+
+	// collector 在同一地址重启后，连接自动恢复
+	collector.server.Stop()
+	restarted, _ := startFakeCollector(t, addr)
+	waitFor(t, 5*time.Second, func() bool {
+		exportTestSpan(tp)
+		return restarted.received() > 0
+	})
+
+	value, ok := otlpConnections.Load("traces")
+	if !ok {
+		t.Fatal("traces connection not tracked")
+	}
+	conn := value.(*grpc.ClientConn)
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if state := conn.GetState(); state != connectivity.Shutdown {
+		t.Errorf("connection state after Shutdown = %s, want SHUTDOWN", state)
+	}
+	// 连接关闭后监视 goroutine 退出并移除连接
+	waitFor(t, time.Second, func() bool {
+		_, ok := otlpConnections.Load("traces")
+		return !ok
+	})
+}
+
+func TestOTLPPartialSuccess(t *testing.T) {
+	collector, addr := startFakeCollector(t, "127.0.0.1:0")
+	collector.partial = &coltracepb.ExportTracePartialSuccess{RejectedSpans: 1, ErrorMessage: "span too large"}
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer mp.Shutdown(context.Background())
+	registerOTLPRejectedCounter(mp.Meter(internalScopeName))
+	t.Cleanup(func() { otlpRejected.Store(nil) })
+
+	// partial success 已由拦截器处理，不应再作为错误交给全局错误处理器
+	var handled []error
+	var handledMu sync.Mutex
+	prevHandler := otel.GetErrorHandler()
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		handledMu.Lock()
+		handled = append(handled, err)
+		handledMu.Unlock()
+	}))
+	t.Cleanup(func() { otel.SetErrorHandler(prevHandler) })
+
+	tp := setupTestOTLPTracing(t, otlpTestConfig(addr))
+	defer tp.Shutdown(context.Background())
+	exportTestSpan(tp)
+
+	_, m, ok := collectMetric(t, reader, "telemetry_otlp_rejected_total")
+	if !ok {
+		t.Fatal("telemetry_otlp_rejected_total not collected")
+	}
+	points := m.Data.(metricdata.Sum[int64]).DataPoints
+	if len(points) != 1 || points[0].Value != 1 {
+		t.Fatalf("rejected data points = %+v, want a single point with value 1", points)
+	}
+	if signal, _ := points[0].Attributes.Value(attribute.Key("signal")); signal.AsString() != "traces" {
+		t.Errorf("signal = %q, want traces", signal.AsString())
+	}
+	handledMu.Lock()
+	defer handledMu.Unlock()
+	if len(handled) != 0 {
+		t.Errorf("error handler received %v, want nothing", handled)
+	}
+}
+
+func TestDialOTLPZeroTimeout(t *testing.T) {
+	if got := otlpDialTimeout(Config{}); got != defaultOTLPDialTimeout {
+		t.Errorf("otlpDialTimeout(zero) = %v, want %v", got, defaultOTLPDialTimeout)
+	}
+
+	// 手工构造、未设置 OTLPDialTimeout 的配置仍能连接
+	_, addr := startFakeCollector(t, "127.0.0.1:0")
+	conn, err := dialOTLP(Config{OTLPEndpoint: addr}, "zero-timeout-test")
+	if err != nil {
+		t.Fatalf("dialOTLP with zero OTLPDialTimeout: %v", err)
+	}
+	defer otlpConnections.Delete("zero-timeout-test")
+	conn.Close()
+	if err := CheckOTLPEndpoint(context.Background(), Config{OTLPEndpoint: addr}); err != nil {
+		t.Errorf("CheckOTLPEndpoint with zero OTLPDialTimeout: %v", err)
+	}
+}
+
+func TestParseOTLPEndpoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		endpoint   string
+		protocol   string
+		want       otlpEndpoint
+		tracesPath string
+		wantErr    bool
+	}{
+		{name: "bare host:port", endpoint: "localhost:4317", want: otlpEndpoint{target: "localhost:4317", protocol: otlpProtocolGRPC}, tracesPath: "/v1/traces"},
+		{name: "http", endpoint: "http://collector:4318", want: otlpEndpoint{target: "collector:4318", protocol: otlpProtocolHTTP}, tracesPath: "/v1/traces"},
+		{name: "https", endpoint: "https://collector:4318", want: otlpEndpoint{target: "collector:4318", protocol: otlpProtocolHTTP, tls: true}, tracesPath: "/v1/traces"},
+		{name: "http with path", endpoint: "http://collector:4318/otlp/", want: otlpEndpoint{target: "collector:4318", protocol: otlpProtocolHTTP, path: "/otlp"}, tracesPath: "/otlp/v1/traces"},
+		{name: "url with explicit grpc", endpoint: "https://collector:4317", protocol: otlpProtocolGRPC, want: otlpEndpoint{target: "collector:4317", protocol: otlpProtocolGRPC, tls: true}, tracesPath: "/v1/traces"},
+		{name: "bare with explicit http", endpoint: "collector:4318", protocol: otlpProtocolHTTP, want: otlpEndpoint{target: "collector:4318", protocol: otlpProtocolHTTP}, tracesPath: "/v1/traces"},
+		{name: "unsupported scheme", endpoint: "ftp://collector:4318", wantErr: true},
+		{name: "missing host", endpoint: "http:///v1", wantErr: true},
+		{name: "unsupported protocol", endpoint: "localhost:4317", protocol: "http/json", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOTLPEndpoint(tt.endpoint, tt.protocol)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseOTLPEndpoint(%q, %q) = %+v, want error", tt.endpoint, tt.protocol, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseOTLPEndpoint(%q, %q): %v", tt.endpoint, tt.protocol, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseOTLPEndpoint(%q, %q) = %+v, want %+v", tt.endpoint, tt.protocol, got, tt.want)
+			}
+			if path := got.signalPath("traces"); path != tt.tracesPath {
+				t.Errorf("signalPath(traces) = %q, want %q", path, tt.tracesPath)
+			}
+		})
+	}
+}
+
+func TestOTLPHTTPExport(t *testing.T) {
+	var mu sync.Mutex
+	paths := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	received := func(key string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return paths[key]
+	}
+
+	cfg := otlpTestConfig(server.URL + "/otlp")
+	if err := CheckOTLPEndpoint(context.Background(), cfg); err != nil {
+		t.Errorf("CheckOTLPEndpoint: %v", err)
+	}
+
+	tp := setupTestOTLPTracing(t, cfg)
+	exportTestSpan(tp)
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("trace Shutdown: %v", err)
+	}
+	if got := received("POST /otlp/v1/traces"); got == 0 {
+		t.Errorf("no trace export received, got requests %v", paths)
+	}
+
+	prev := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+	cfg.EnableMetrics = true
+	mp, err := SetupMetrics(cfg)
+	if err != nil {
+		t.Fatalf("SetupMetrics: %v", err)
+	}
+	counter, _ := mp.meterProvider.Meter("test").Int64Counter("otlp_http_test_total")
+	counter.Add(context.Background(), 1)
+	// Shutdown 会做最后一次收集并导出
+	if err := mp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("metric Shutdown: %v", err)
+	}
+	if got := received("POST /otlp/v1/metrics"); got == 0 {
+		t.Errorf("no metric export received, got requests %v", paths)
+	}
+}
+
+// startHealthCollector 启动仅注册 gRPC 健康检查服务的 collector，返回监听地址
+func startHealthCollector(t *testing.T, status healthpb.HealthCheckResponse_ServingStatus) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("", status)
+	healthpb.RegisterHealthServer(server, hs)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+// unusedAddr 返回当前无人监听的本地地址
+func unusedAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestCheckOTLPEndpoint(t *testing.T) {
+	_, plain := startFakeCollector(t, "127.0.0.1:0")
+	serving := startHealthCollector(t, healthpb.HealthCheckResponse_SERVING)
+	notServing := startHealthCollector(t, healthpb.HealthCheckResponse_NOT_SERVING)
+	closed := unusedAddr(t)
+
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  bool
+	}{
+		{name: "no endpoint", endpoint: ""},
+		{name: "collector without health service", endpoint: plain},
+		{name: "serving", endpoint: serving},
+		{name: "not serving", endpoint: notServing, wantErr: true},
+		{name: "unreachable grpc", endpoint: closed, wantErr: true},
+		{name: "unreachable http", endpoint: "http://" + closed, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := otlpTestConfig(tt.endpoint)
+			cfg.OTLPDialTimeout = 500 * time.Millisecond
+			start := time.Now()
+			err := CheckOTLPEndpoint(context.Background(), cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckOTLPEndpoint(%q) = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+			}
+			// 不可达时在拨号超时内返回
+			if elapsed := time.Since(start); elapsed > 2*cfg.OTLPDialTimeout {
+				t.Errorf("CheckOTLPEndpoint took %v, want within %v", elapsed, cfg.OTLPDialTimeout)
+			}
+		})
+	}
+}
+
+func TestNewProviderPrecheckEndpoint(t *testing.T) {
+	cfg := otlpTestConfig(unusedAddr(t))
+	cfg.OTLPDialTimeout = 500 * time.Millisecond
+	cfg.PrecheckEndpoint = true
+	cfg.EnablePrometheusExporter = false
+
+	p, err := NewProvider(cfg)
+	if err == nil {
+		p.Shutdown(context.Background())
+		t.Fatal("NewProvider with unreachable endpoint succeeded, want precheck error")
+	}
+	if !strings.Contains(err.Error(), "precheck") {
+		t.Errorf("NewProvider error = %v, want precheck failure", err)
+	}
+
+	// collector 可达时正常创建
+	_, addr := startFakeCollector(t, "127.0.0.1:0")
+	cfg.OTLPEndpoint = addr
+	newTestProvider(t, cfg)
+}
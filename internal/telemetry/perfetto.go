@@ -0,0 +1,148 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// maxPerfettoEvents 内存中最多缓存的 span 数，超出的 span 被丢弃
+const maxPerfettoEvents = 100000
+
+// perfettoEvent Chrome Trace Event 格式中的一个事件（ph=X 为完整的持续事件，ph=M 为元数据）
+type perfettoEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase string         `json:"ph"`
+	TS    int64          `json:"ts"`
+	Dur   int64          `json:"dur,omitempty"`
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// perfettoExporter 将结束的 span 缓存在内存中，关闭时以 Chrome Trace Event JSON 写入文件，
+// 可直接在 Perfetto（ui.perfetto.dev）或 chrome://tracing 中打开
+// 每个 trace 对应一个进程、每个 span 对应一个线程；时间单位为微秒。仅用于本地性能分析
+type perfettoExporter struct {
+	path string
+
+	mu      sync.Mutex
+	events  []perfettoEvent
+	pids    map[trace.TraceID]int
+	nextTID int
+	dropped int
+
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+func newPerfettoExporter(path string) *perfettoExporter {
+	return &perfettoExporter{
+		path: path,
+		pids: make(map[trace.TraceID]int),
+	}
+}
+
+// ExportSpans 将 span 转换为持续事件缓存，不写入磁盘
+func (e *perfettoExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, s := range spans {
+		if len(e.events) >= maxPerfettoEvents {
+			e.dropped++
+			continue
+		}
+		e.appendSpan(s)
+	}
+	return nil
+}
+
+// appendSpan 添加 span 对应的持续事件，trace 与 span 首次出现时添加命名的元数据事件，调用方需持有 mu
+func (e *perfettoExporter) appendSpan(s sdktrace.ReadOnlySpan) {
+	sc := s.SpanContext()
+	pid, ok := e.pids[sc.TraceID()]
+	if !ok {
+		pid = len(e.pids) + 1
+		e.pids[sc.TraceID()] = pid
+		e.events = append(e.events, perfettoEvent{
+			Name:  "process_name",
+			Phase: "M",
+			PID:   pid,
+			Args:  map[string]any{"name": "trace " + sc.TraceID().String()},
+		})
+	}
+	e.nextTID++
+	tid := e.nextTID
+	e.events = append(e.events, perfettoEvent{
+		Name:  "thread_name",
+		Phase: "M",
+		PID:   pid,
+		TID:   tid,
+		Args:  map[string]any{"name": s.Name()},
+	})
+
+	args := make(map[string]any, len(s.Attributes())+3)
+	for _, kv := range s.Attributes() {
+		args[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	args["span_id"] = sc.SpanID().String()
+	if parent := s.Parent(); parent.IsValid() {
+		args["parent_span_id"] = parent.SpanID().String()
+	}
+	args["span.kind"] = s.SpanKind().String()
+
+	e.events = append(e.events, perfettoEvent{
+		Name:  s.Name(),
+		Cat:   s.InstrumentationScope().Name,
+		Phase: "X",
+		TS:    s.StartTime().UnixMicro(),
+		Dur:   s.EndTime().Sub(s.StartTime()).Microseconds(),
+		PID:   pid,
+		TID:   tid,
+		Args:  args,
+	})
+}
+
+// Shutdown 将缓存的事件写入文件，多次调用只写入一次
+func (e *perfettoExporter) Shutdown(context.Context) error {
+	e.shutdownOnce.Do(func() {
+		e.shutdownErr = e.write()
+	})
+	return e.shutdownErr
+}
+
+// write 以 {"traceEvents": [...]} 格式写入文件
+func (e *perfettoExporter) write() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.dropped > 0 {
+		zap.L().Warn("Perfetto export buffer full, spans dropped",
+			zap.Int("dropped", e.dropped),
+			zap.Int("max_events", maxPerfettoEvents),
+		)
+	}
+
+	data, err := json.Marshal(struct {
+		TraceEvents     []perfettoEvent `json:"traceEvents"`
+		DisplayTimeUnit string          `json:"displayTimeUnit"`
+	}{
+		TraceEvents:     e.events,
+		DisplayTimeUnit: "ms",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal perfetto trace: %w", err)
+	}
+	if err := os.WriteFile(e.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write perfetto trace: %w", err)
+	}
+	return nil
+}
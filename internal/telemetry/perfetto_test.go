@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestPerfettoExport(t *testing.T) {
+	prevTP := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prevTP) })
+	cfg := testProviderConfig()
+	cfg.PerfettoExportPath = filepath.Join(t.TempDir(), "trace.json")
+	tp, err := setupTracing(cfg, metricnoop.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("setupTracing: %v", err)
+	}
+
+	tracer := tp.provider.Tracer("perfetto-test")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	parent.End()
+	_, other := tracer.Start(context.Background(), "other-trace")
+	other.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	data, err := os.ReadFile(cfg.PerfettoExportPath)
+	if err != nil {
+		t.Fatalf("read perfetto file: %v", err)
+	}
+	var out struct {
+		TraceEvents []perfettoEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("perfetto file is not valid JSON: %v", err)
+	}
+
+	spans := map[string]perfettoEvent{}
+	processes := map[int]bool{}
+	for _, e := range out.TraceEvents {
+		switch {
+		case e.Phase == "X":
+			spans[e.Name] = e
+		case e.Phase == "M" && e.Name == "process_name":
+			processes[e.PID] = true
+		}
+	}
+	if len(spans) != 3 || len(processes) != 2 {
+		t.Fatalf("got %d spans in %d processes, want 3 spans in 2 processes", len(spans), len(processes))
+	}
+
+	// 同一 trace 的 span 属于同一进程、各占一个线程，子 span 不早于父 span 开始
+	p, c := spans["parent"], spans["child"]
+	if p.PID != c.PID || p.TID == c.TID {
+		t.Errorf("parent pid/tid = %d/%d, child = %d/%d, want the same process and different threads", p.PID, p.TID, c.PID, c.TID)
+	}
+	if spans["other-trace"].PID == p.PID {
+		t.Error("span from another trace shares the parent's process")
+	}
+	if c.TS < p.TS {
+		t.Errorf("child starts at %d, before parent at %d", c.TS, p.TS)
+	}
+	if c.Args["parent_span_id"] != p.Args["span_id"] {
+		t.Errorf("child parent_span_id = %v, want %v", c.Args["parent_span_id"], p.Args["span_id"])
+	}
+}
+
+// failingExporter 每次导出都失败的导出器，模拟不可达的 OTLP collector
+type failingExporter struct{}
+
+func (failingExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	return errors.New("collector unreachable")
+}
+
+func (failingExporter) Shutdown(context.Context) error { return nil }
+
+func TestPerfettoExportSurvivesFailingExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.json")
+	perfetto := newPerfettoExporter(path)
+	exporter := newMultiSpanExporter(failingExporter{}, perfetto)
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, parent := tp.Tracer("perfetto-test").Start(context.Background(), "parent")
+	_, child := tp.Tracer("perfetto-test").Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	if err := exporter.ExportSpans(context.Background(), recorder.Ended()); err == nil {
+		t.Error("ExportSpans error = nil, want the failing exporter's error")
+	}
+	if err := exporter.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read perfetto file: %v", err)
+	}
+	// 按 Chrome Trace Event 格式校验原始 JSON 结构
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("perfetto file is not valid JSON: %v", err)
+	}
+	if out["displayTimeUnit"] != "ms" {
+		t.Errorf("displayTimeUnit = %v, want ms", out["displayTimeUnit"])
+	}
+	events, ok := out["traceEvents"].([]any)
+	if !ok {
+		t.Fatalf("traceEvents = %T, want an array", out["traceEvents"])
+	}
+	phases := map[string]int{}
+	for _, raw := range events {
+		event := raw.(map[string]any)
+		for _, key := range []string{"name", "ph", "pid", "tid", "ts"} {
+			if _, ok := event[key]; !ok {
+				t.Errorf("event %v missing %q", event, key)
+			}
+		}
+		phases[event["ph"].(string)]++
+	}
+	// 一个 trace 的进程元数据、两个 span 各自的线程元数据与持续事件
+	if phases["X"] != 2 || phases["M"] != 3 {
+		t.Errorf("event phases = %v, want 2 X and 3 M", phases)
+	}
+}
@@ -0,0 +1,285 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanProcessors 根据配置构造附加的 span 处理器，meter 用于处理器自身的指标
+func spanProcessors(cfg Config, meter metric.Meter) ([]sdktrace.SpanProcessor, error) {
+	var processors []sdktrace.SpanProcessor
+
+	if len(cfg.CopyBaggageToAttributes) > 0 {
+		processors = append(processors, NewBaggageSpanProcessor(cfg.CopyBaggageToAttributes...))
+	}
+	if len(cfg.DefaultSpanAttributes) > 0 {
+		processors = append(processors, NewDefaultAttributesSpanProcessor(cfg.DefaultSpanAttributes))
+	}
+	if cfg.EnableK8sSpanEnrichment {
+		if k8s := NewK8sSpanProcessor(); k8s != nil {
+			processors = append(processors, k8s)
+		}
+	}
+	if cfg.TrackActiveSpans {
+		active, err := NewActiveSpanProcessor(meter)
+		if err != nil {
+			return nil, err
+		}
+		processors = append(processors, active)
+	}
+	if cfg.TraceScopedDebugBuffer {
+		processors = append(processors, traceLogBufferProcessor{})
+	}
+	if cfg.SpanEventsAsLogs {
+		processors = append(processors, NewSpanEventLogProcessor(nil))
+	}
+
+	return processors, nil
+}
+
+// BaggageSpanProcessor 在 span 开始时将指定的 baggage 成员复制为 span 属性
+type BaggageSpanProcessor struct {
+	keys []string
+}
+
+// NewBaggageSpanProcessor 创建 baggage 复制处理器
+func NewBaggageSpanProcessor(keys ...string) *BaggageSpanProcessor {
+	return &BaggageSpanProcessor{keys: keys}
+}
+
+// OnStart 从启动上下文读取 baggage 并设置为 span 属性
+func (p *BaggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	bag := baggage.FromContext(parent)
+	if bag.Len() == 0 {
+		return
+	}
+
+	for _, key := range p.keys {
+		member := bag.Member(key)
+		if member.Key() == "" {
+			continue
+		}
+		s.SetAttributes(attribute.String(key, member.Value()))
+	}
+}
+
+// OnEnd 无需处理
+func (p *BaggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+// Shutdown 无需清理
+func (p *BaggageSpanProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush 无需刷新
+func (p *BaggageSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// DefaultAttributesSpanProcessor 在 span 开始时为每个 span 设置固定的默认属性
+type DefaultAttributesSpanProcessor struct {
+	attrs []attribute.KeyValue
+}
+
+// NewDefaultAttributesSpanProcessor 创建默认属性处理器
+func NewDefaultAttributesSpanProcessor(attributes map[string]string) *DefaultAttributesSpanProcessor {
+	attrs := make([]attribute.KeyValue, 0, len(attributes))
+	for k, v := range attributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	return &DefaultAttributesSpanProcessor{attrs: attrs}
+}
+
+// OnStart 设置默认属性
+func (p *DefaultAttributesSpanProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	s.SetAttributes(p.attrs...)
+}
+
+// OnEnd 无需处理
+func (p *DefaultAttributesSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+// Shutdown 无需清理
+func (p *DefaultAttributesSpanProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush 无需刷新
+func (p *DefaultAttributesSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// k8sEnvVars downward API 环境变量到 k8s 语义约定属性的映射（按顺序取第一个存在的变量）
+var k8sEnvVars = []struct {
+	key  attribute.Key
+	envs []string
+}{
+	{semconv.K8SPodNameKey, []string{"POD_NAME", "K8S_POD_NAME"}},
+	{semconv.K8SNamespaceNameKey, []string{"POD_NAMESPACE", "K8S_NAMESPACE_NAME"}},
+	{semconv.K8SNodeNameKey, []string{"NODE_NAME", "K8S_NODE_NAME"}},
+}
+
+// K8sSpanProcessor 将 Kubernetes pod 元数据设置为 span 属性
+type K8sSpanProcessor struct {
+	attrs []attribute.KeyValue
+}
+
+// NewK8sSpanProcessor 在启动时读取一次 downward API 环境变量并创建处理器
+// 若未找到任何相关环境变量（如不在 k8s 中运行）则返回 nil
+func NewK8sSpanProcessor() *K8sSpanProcessor {
+	var attrs []attribute.KeyValue
+	for _, item := range k8sEnvVars {
+		for _, env := range item.envs {
+			if value := os.Getenv(env); value != "" {
+				attrs = append(attrs, item.key.String(value))
+				break
+			}
+		}
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return &K8sSpanProcessor{attrs: attrs}
+}
+
+// OnStart 设置 k8s 属性
+func (p *K8sSpanProcessor) OnStart(_ context.Context, s sdktrace.ReadWriteSpan) {
+	s.SetAttributes(p.attrs...)
+}
+
+// OnEnd 无需处理
+func (p *K8sSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+// Shutdown 无需清理
+func (p *K8sSpanProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush 无需刷新
+func (p *K8sSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// ActiveSpanProcessor 通过 telemetry_active_spans 指标跟踪已开始但尚未结束的 span 数量（按 span 名称）
+// 持续上升的数值通常意味着遗漏了 span.End()
+type ActiveSpanProcessor struct {
+	active metric.Int64UpDownCounter
+	// 记录 span 开始时的名称，避免 span 中途改名导致计数无法归零
+	names sync.Map
+}
+
+// NewActiveSpanProcessor 在给定 meter 上创建活跃 span 跟踪处理器，meter 通常来自与 TracerProvider 一同构建的 MeterProvider
+func NewActiveSpanProcessor(meter metric.Meter) (*ActiveSpanProcessor, error) {
+	active, err := meter.Int64UpDownCounter("telemetry_active_spans",
+		metric.WithDescription("Number of spans started but not yet ended"),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create active spans counter: %w", err)
+	}
+	return &ActiveSpanProcessor{active: active}, nil
+}
+
+// OnStart 增加活跃 span 计数
+func (p *ActiveSpanProcessor) OnStart(ctx context.Context, s sdktrace.ReadWriteSpan) {
+	name := s.Name()
+	p.names.Store(s.SpanContext().SpanID(), name)
+	p.active.Add(ctx, 1, metric.WithAttributes(attribute.String("span.name", name)))
+}
+
+// OnEnd 减少活跃 span 计数
+func (p *ActiveSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	name, ok := p.names.LoadAndDelete(s.SpanContext().SpanID())
+	if !ok {
+		return
+	}
+	p.active.Add(context.Background(), -1, metric.WithAttributes(attribute.String("span.name", name.(string))))
+}
+
+// Shutdown 无需清理
+func (p *ActiveSpanProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush 无需刷新
+func (p *ActiveSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// SpanEventLogProcessor 在已采样 span 结束时将其每个事件作为一条关联到该 span 的日志记录发送，
+// 记录的事件名称与 body 为事件名称，时间戳与属性取自事件，使 span 事件可在日志后端中检索
+// 记录经 OTel 日志 API 发送，LoggerProvider 未接入导出器时不产生输出
+type SpanEventLogProcessor struct {
+	logger log.Logger
+}
+
+// NewSpanEventLogProcessor 创建 span 事件日志处理器，provider 为 nil 时使用全局 LoggerProvider
+func NewSpanEventLogProcessor(provider log.LoggerProvider) *SpanEventLogProcessor {
+	if provider == nil {
+		provider = global.GetLoggerProvider()
+	}
+	return &SpanEventLogProcessor{logger: scopedLogger(provider, internalScopeName)}
+}
+
+// OnStart 无需处理
+func (p *SpanEventLogProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+// OnEnd 将 span 事件逐条发送为日志记录，未采样的 span 被跳过
+func (p *SpanEventLogProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	sc := s.SpanContext()
+	if !sc.IsSampled() || len(s.Events()) == 0 {
+		return
+	}
+
+	// 日志记录的 trace_id/span_id 取自上下文中的 span
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	observed := time.Now()
+	for _, event := range s.Events() {
+		var record log.Record
+		record.SetEventName(event.Name)
+		record.SetBody(log.StringValue(event.Name))
+		record.SetTimestamp(event.Time)
+		record.SetObservedTimestamp(observed)
+		record.SetSeverity(log.SeverityInfo)
+		for _, kv := range event.Attributes {
+			record.AddAttributes(log.KeyValueFromAttribute(kv))
+		}
+		p.logger.Emit(ctx, record)
+	}
+}
+
+// Shutdown 无需清理
+func (p *SpanEventLogProcessor) Shutdown(context.Context) error { return nil }
+
+// ForceFlush 无需刷新
+func (p *SpanEventLogProcessor) ForceFlush(context.Context) error { return nil }
+
+// DurationFilterSpanProcessor 丢弃耗时不超过 minDuration 的 span，其余 span 转发给下游处理器
+// 用于过滤有缺陷的插桩产生的零耗时 span；minDuration 为 0 时只丢弃零耗时 span
+type DurationFilterSpanProcessor struct {
+	minDuration time.Duration
+	next        sdktrace.SpanProcessor
+}
+
+// NewDurationFilterSpanProcessor 创建耗时过滤处理器，next 通常为批处理器
+func NewDurationFilterSpanProcessor(minDuration time.Duration, next sdktrace.SpanProcessor) *DurationFilterSpanProcessor {
+	return &DurationFilterSpanProcessor{minDuration: minDuration, next: next}
+}
+
+// OnStart 转发给下游处理器
+func (p *DurationFilterSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd 仅转发耗时超过 minDuration 的 span
+func (p *DurationFilterSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.EndTime().Sub(s.StartTime()) <= p.minDuration {
+		return
+	}
+	p.next.OnEnd(s)
+}
+
+// Shutdown 关闭下游处理器
+func (p *DurationFilterSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush 刷新下游处理器
+func (p *DurationFilterSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
@@ -0,0 +1,165 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/log/logtest"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// activeSpans 返回 telemetry_active_spans 中指定 span 名称的当前值
+func activeSpans(t *testing.T, reader sdkmetric.Reader, name string) int64 {
+	t.Helper()
+	_, m, ok := collectMetric(t, reader, "telemetry_active_spans")
+	if !ok {
+		return 0
+	}
+	for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+		if v, _ := dp.Attributes.Value(attribute.Key("span.name")); v.AsString() == name {
+			return dp.Value
+		}
+	}
+	return 0
+}
+
+func TestActiveSpanProcessor(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer mp.Shutdown(context.Background())
+
+	active, err := NewActiveSpanProcessor(mp.Meter(internalScopeName))
+	if err != nil {
+		t.Fatalf("NewActiveSpanProcessor: %v", err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(active))
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("test")
+
+	_, first := tracer.Start(context.Background(), "work")
+	_, second := tracer.Start(context.Background(), "work")
+	if got := activeSpans(t, reader, "work"); got != 2 {
+		t.Fatalf("active spans = %d, want 2", got)
+	}
+
+	// 中途改名的 span 仍按开始时的名称计数
+	second.SetName("renamed")
+	first.End()
+	second.End()
+	if got := activeSpans(t, reader, "work"); got != 0 {
+		t.Errorf("active spans after End = %d, want 0", got)
+	}
+}
+
+func TestSpanEventLogProcessor(t *testing.T) {
+	recorder := logtest.NewRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(NewSpanEventLogProcessor(recorder)))
+	defer tp.Shutdown(context.Background())
+
+	eventTime := time.Unix(1700000000, 0)
+	_, span := tp.Tracer("test").Start(context.Background(), "checkout")
+	span.AddEvent("cache.miss", trace.WithTimestamp(eventTime), trace.WithAttributes(attribute.String("cache.key", "cart:1")))
+	span.End()
+	sc := span.SpanContext()
+
+	var records []logtest.EmittedRecord
+	for _, scope := range recorder.Result() {
+		records = append(records, scope.Records...)
+	}
+	if len(records) != 1 {
+		t.Fatalf("emitted %d log records, want 1", len(records))
+	}
+	rec := records[0]
+	if got := trace.SpanContextFromContext(rec.Context()); got.TraceID() != sc.TraceID() || got.SpanID() != sc.SpanID() {
+		t.Errorf("record correlated with %s/%s, want %s/%s", got.TraceID(), got.SpanID(), sc.TraceID(), sc.SpanID())
+	}
+	if rec.EventName() != "cache.miss" || rec.Body().AsString() != "cache.miss" {
+		t.Errorf("event name %q, body %q, want cache.miss", rec.EventName(), rec.Body().AsString())
+	}
+	if !rec.Timestamp().Equal(eventTime) {
+		t.Errorf("timestamp = %v, want the event time %v", rec.Timestamp(), eventTime)
+	}
+	var key string
+	rec.WalkAttributes(func(kv log.KeyValue) bool {
+		if kv.Key == "cache.key" {
+			key = kv.Value.AsString()
+		}
+		return true
+	})
+	if key != "cart:1" {
+		t.Errorf("cache.key = %q, want cart:1", key)
+	}
+}
+
+func TestDefaultSpanAttributes(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.DefaultSpanAttributes = map[string]string{"region": "eu-west-1", "cluster": "blue"}
+	processors, err := spanProcessors(cfg, metricnoop.Meter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := make([]sdktrace.TracerProviderOption, 0, len(processors))
+	for _, p := range processors {
+		opts = append(opts, sdktrace.WithSpanProcessor(p))
+	}
+	exporter := setupTestTracing(t, opts...)
+
+	ctx, parent := Tracer("test").Start(context.Background(), "parent", trace.WithAttributes(attribute.String("data.id", "42")))
+	_, child := Tracer("test").Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	for _, name := range []string{"parent", "child"} {
+		span, ok := findSpan(exporter.GetSpans(), name)
+		if !ok {
+			t.Fatalf("%s span not exported", name)
+		}
+		for key, want := range cfg.DefaultSpanAttributes {
+			if got, _ := attrValue(span.Attributes, key); got != want {
+				t.Errorf("%s %s = %q, want %q", name, key, got, want)
+			}
+		}
+	}
+	parentSpan, _ := findSpan(exporter.GetSpans(), "parent")
+	if got, _ := attrValue(parentSpan.Attributes, "data.id"); got != "42" {
+		t.Errorf("parent data.id = %q, want the explicit attribute kept", got)
+	}
+}
+
+func TestDurationFilterSpanProcessor(t *testing.T) {
+	tests := []struct {
+		name        string
+		minDuration time.Duration
+		duration    time.Duration
+		forwarded   bool
+	}{
+		{name: "zero duration dropped", duration: 0},
+		{name: "normal span forwarded", duration: time.Millisecond, forwarded: true},
+		{name: "at threshold dropped", minDuration: 5 * time.Millisecond, duration: 5 * time.Millisecond},
+		{name: "above threshold forwarded", minDuration: 5 * time.Millisecond, duration: 6 * time.Millisecond, forwarded: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(
+				NewDurationFilterSpanProcessor(tt.minDuration, sdktrace.NewSimpleSpanProcessor(exporter))))
+			defer tp.Shutdown(context.Background())
+
+			start := time.Now()
+			_, span := tp.Tracer("test").Start(context.Background(), "op", trace.WithTimestamp(start))
+			span.End(trace.WithTimestamp(start.Add(tt.duration)))
+
+			if got := len(exporter.GetSpans()) == 1; got != tt.forwarded {
+				t.Errorf("span of %v with minDuration %v forwarded = %v, want %v", tt.duration, tt.minDuration, got, tt.forwarded)
+			}
+		})
+	}
+}
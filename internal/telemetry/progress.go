@@ -0,0 +1,88 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ProgressOption 配置进度上报器的选项
+type ProgressOption func(*Progress)
+
+// WithProgressPercentStep 设置两次上报之间的最小进度增量（百分比，默认 10）
+func WithProgressPercentStep(step float64) ProgressOption {
+	return func(p *Progress) {
+		p.percentStep = step
+	}
+}
+
+// WithProgressInterval 设置两次上报之间的最大时间间隔（默认 1s）
+func WithProgressInterval(interval time.Duration) ProgressOption {
+	return func(p *Progress) {
+		p.interval = interval
+	}
+}
+
+// Progress 以 span 事件的形式上报长耗时操作的进度
+type Progress struct {
+	ctx         context.Context
+	total       int
+	percentStep float64
+	interval    time.Duration
+
+	mu           sync.Mutex
+	completed    int
+	lastPercent  float64
+	lastReported time.Time
+}
+
+// NewProgress 为当前 span 创建进度上报器
+// 进度增量达到 percentStep 或距上次上报超过 interval 时才会添加 progress 事件，完成时总会上报
+func NewProgress(ctx context.Context, total int, opts ...ProgressOption) *Progress {
+	p := &Progress{
+		ctx:          ctx,
+		total:        total,
+		percentStep:  10,
+		interval:     time.Second,
+		lastReported: time.Now(),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Step 记录完成了 n 个单位的工作，并在满足节流条件时添加 progress 事件
+func (p *Progress) Step(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.completed += n
+	if p.completed > p.total {
+		p.completed = p.total
+	}
+
+	percent := 100.0
+	if p.total > 0 {
+		percent = float64(p.completed) / float64(p.total) * 100
+	}
+
+	done := p.completed >= p.total
+	if !done && percent-p.lastPercent < p.percentStep && time.Since(p.lastReported) < p.interval {
+		return
+	}
+	if done && p.lastPercent >= 100 {
+		// 完成事件只上报一次
+		return
+	}
+
+	p.lastPercent = percent
+	p.lastReported = time.Now()
+	AddSpanEvent(p.ctx, "progress",
+		attribute.Int("completed", p.completed),
+		attribute.Int("total", p.total),
+		attribute.Float64("percent", percent),
+	)
+}
@@ -0,0 +1,49 @@
+package telemetry
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/sdk/metric"
+)
+
+// newPrometheusReader 创建 Prometheus 导出器 reader 及其独立的注册表
+// PrometheusConstLabels 会作为常量标签附加到所有导出的序列上
+func newPrometheusReader(cfg Config) (metric.Reader, *prometheus.Registry, error) {
+	registry := prometheus.NewRegistry()
+
+	var registerer prometheus.Registerer = registry
+	if len(cfg.PrometheusConstLabels) > 0 {
+		registerer = prometheus.WrapRegistererWith(prometheus.Labels(cfg.PrometheusConstLabels), registry)
+	}
+
+	opts := []otelprom.Option{otelprom.WithRegisterer(registerer)}
+	if cfg.PrometheusNamespace != "" {
+		opts = append(opts, otelprom.WithNamespace(cfg.PrometheusNamespace))
+	}
+
+	exporter, err := otelprom.New(opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create prometheus exporter: %w", err)
+	}
+	return exporter, registry, nil
+}
+
+// PrometheusHandler 返回 Prometheus 抓取端点的 HTTP handler，未启用 Prometheus 导出器时返回 404
+func (mp *MetricProvider) PrometheusHandler() http.Handler {
+	if mp == nil || mp.promRegistry == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(mp.promRegistry, promhttp.HandlerOpts{})
+}
+
+// PrometheusNamespace 返回 Prometheus 指标名使用的命名空间前缀
+func (mp *MetricProvider) PrometheusNamespace() string {
+	if mp == nil {
+		return ""
+	}
+	return mp.promNamespace
+}
@@ -3,11 +3,19 @@ package telemetry
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
 )
 
 // Provider 整合所有遥测功能的提供者
@@ -19,6 +27,16 @@ type Provider struct {
 	startTime      time.Time
 	shutdownErrors metric.Int64Counter
 	providerUp     metric.Int64ObservableGauge
+	shutdownOnce   sync.Once
+	shutdownErr    error
+	shutdownReport ShutdownReport
+	callbackMu     sync.Mutex
+	callbacks      []*metricCallback
+	observables    map[metric.Observable]observableFactory
+	hookMu         sync.Mutex
+	shutdownHooks  []func(context.Context) error
+	configMu       sync.RWMutex
+	stopWatch      func()
 }
 
 // NewProvider 创建一个新的遥测功能提供者
@@ -27,6 +45,31 @@ func NewProvider(cfg Config) (*Provider, error) {
 		config: cfg,
 	}
 
+	// 配置错误分类器
+	if cfg.ErrorClassifier != nil {
+		SetErrorClassifier(cfg.ErrorClassifier)
+	}
+
+	// 配置默认 tracer 名称
+	if cfg.TracerName != "" {
+		SetDefaultTracerName(cfg.TracerName)
+	} else {
+		SetDefaultTracerName(cfg.ServiceName)
+	}
+
+	// 配置默认 instrumentation scope 版本
+	if cfg.InstrumentationVersion != "" {
+		SetDefaultInstrumentationVersion(cfg.InstrumentationVersion)
+	} else {
+		SetDefaultInstrumentationVersion(cfg.ServiceVersion)
+	}
+
+	// 配置应用属性命名空间
+	SetAttributeNamespace(cfg.AttributeNamespace)
+
+	// 配置 span 内存分配统计
+	SetSpanAllocsEnabled(cfg.EnableSpanAllocs)
+
 	// 初始化日志
 	logProvider, err := SetupLogging(cfg)
 	if err != nil {
@@ -34,73 +77,438 @@ func NewProvider(cfg Config) (*Provider, error) {
 	}
 	provider.logProvider = logProvider
 
-	// 初始化 trace
-	traceProvider, err := SetupTracing(cfg)
-	if err != nil {
-		logProvider.Shutdown()
-		return nil, fmt.Errorf("failed to setup tracing: %w", err)
+	// 将 SDK 内部错误输出到日志
+	installOTelErrorHandler()
+
+	// 检查 OTLP collector 是否可达
+	if cfg.PrecheckEndpoint {
+		if err := CheckOTLPEndpoint(context.Background(), cfg); err != nil {
+			logProvider.Shutdown()
+			return nil, fmt.Errorf("OTLP endpoint precheck failed: %w", err)
+		}
 	}
-	provider.traceProvider = traceProvider
 
-	// 初始化 metrics
+	// 初始化 metrics，先于 trace 完成，使采样决定等 trace 自观测指标绑定到本 Provider 的 meter provider
 	if cfg.EnableMetrics {
 		metricProvider, err := SetupMetrics(cfg)
 		if err != nil {
 			logProvider.Shutdown()
-			traceProvider.Shutdown(context.Background())
 			return nil, fmt.Errorf("failed to setup metrics: %w", err)
 		}
 		provider.metricProvider = metricProvider
 	}
 
+	// 初始化 trace
+	traceProvider, err := setupTracing(cfg, provider.meterProvider())
+	if err != nil {
+		logProvider.Shutdown()
+		if provider.metricProvider != nil {
+			provider.metricProvider.Shutdown(context.Background())
+		}
+		return nil, fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	provider.traceProvider = traceProvider
+
 	provider.initHealthMetrics()
+	provider.logInitialized()
 
 	return provider, nil
 }
 
-// Shutdown 关闭所有遥测功能
+// logInitialized 输出一条汇总已解析配置的初始化日志
+func (p *Provider) logInitialized() {
+	cfg := p.config
+
+	var exporters []string
+	if cfg.EnableConsoleExporter {
+		exporters = append(exporters, "console")
+	}
+	otlpTarget, otlpTLS := "", false
+	if cfg.OTLPEndpoint != "" {
+		if endpoint, err := parseOTLPEndpoint(cfg.OTLPEndpoint, cfg.OTLPProtocol); err == nil {
+			exporters = append(exporters, "otlp/"+endpoint.protocol)
+			otlpTarget = endpoint.target
+			otlpTLS = cfg.TLSConfig.Enabled || endpoint.tls
+		}
+	}
+	if cfg.PerfettoExportPath != "" {
+		exporters = append(exporters, "perfetto")
+	}
+
+	metricEndpoint := ""
+	if cfg.EnableMetrics {
+		metricEndpoint = otlpTarget
+	}
+	logSink, _ := resolveLogSink(cfg.LogSink)
+
+	Logger().Info("telemetry initialized",
+		zap.String("service_name", cfg.ServiceName),
+		zap.String("service_version", cfg.ServiceVersion),
+		zap.String("environment", cfg.Environment),
+		zap.String("trace_endpoint", otlpTarget),
+		zap.String("metric_endpoint", metricEndpoint),
+		zap.String("log_sink", logSink),
+		zap.Strings("exporters", exporters),
+		zap.Bool("otlp_tls", otlpTLS),
+		zap.Float64("sampling_ratio", cfg.SamplingRatio),
+		zap.Bool("parent_based_sampling", cfg.ParentBasedSampling),
+		zap.Bool("traces_enabled", p.traceProvider != nil),
+		zap.Bool("metrics_enabled", p.metricProvider != nil),
+		zap.Bool("logs_enabled", p.logProvider != nil),
+	)
+}
+
+// SignalShutdownResult 单个信号的关闭结果
+type SignalShutdownResult struct {
+	// 该信号是否已启用
+	Enabled bool
+	// 是否成功刷新并关闭
+	Flushed bool
+	// 关闭耗时
+	Duration time.Duration
+	// 关闭失败时的错误
+	Err error
+}
+
+// ShutdownReport 各信号的关闭结果，供部署工具确认遥测数据在退出前已刷新
+type ShutdownReport struct {
+	Metrics SignalShutdownResult
+	Traces  SignalShutdownResult
+	Logs    SignalShutdownResult
+}
+
+// Shutdown 关闭所有遥测功能，重复调用时直接返回首次关闭的结果
 func (p *Provider) Shutdown(ctx context.Context) error {
-	var errs []error
+	_, err := p.ShutdownWithReport(ctx)
+	return err
+}
+
+// ShutdownWithReport 关闭所有遥测功能并返回各信号的关闭结果，重复调用时直接返回首次关闭的结果
+func (p *Provider) ShutdownWithReport(ctx context.Context) (ShutdownReport, error) {
+	p.shutdownOnce.Do(func() {
+		p.shutdownReport, p.shutdownErr = p.shutdown(ctx)
+	})
+	return p.shutdownReport, p.shutdownErr
+}
+
+// shutdownSignal 执行单个信号的关闭并记录结果
+func shutdownSignal(fn func() error) SignalShutdownResult {
+	start := time.Now()
+	err := fn()
+	return SignalShutdownResult{
+		Enabled:  true,
+		Flushed:  err == nil,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+}
+
+// shutdown 依次关闭 metrics、trace，执行关闭钩子，最后关闭日志
+func (p *Provider) shutdown(ctx context.Context) (ShutdownReport, error) {
+	var (
+		report ShutdownReport
+		errs   []error
+	)
+
+	// 停止配置文件监听，并取得当前的 provider（可能已被 UpdateResourceAttribute 替换）
+	p.configMu.Lock()
+	if p.stopWatch != nil {
+		p.stopWatch()
+		p.stopWatch = nil
+	}
+	traceProvider, metricProvider, shutdownErrors := p.traceProvider, p.metricProvider, p.shutdownErrors
+	p.configMu.Unlock()
+
+	// 注销自定义指标回调
+	p.callbackMu.Lock()
+	for _, c := range p.callbacks {
+		if c.reg == nil {
+			continue
+		}
+		if err := c.reg.Unregister(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to unregister metric callback: %w", err))
+		}
+	}
+	p.callbacks = nil
+	p.callbackMu.Unlock()
 
 	// 关闭 metrics
-	if p.metricProvider != nil {
-		if err := p.metricProvider.Shutdown(ctx); err != nil {
+	if metricProvider != nil {
+		report.Metrics = shutdownSignal(func() error {
+			return metricProvider.Shutdown(ctx)
+		})
+		if err := report.Metrics.Err; err != nil {
 			errs = append(errs, fmt.Errorf("failed to shutdown metrics: %w", err))
 		}
 	}
 
 	// 关闭 trace
-	if p.traceProvider != nil {
-		if err := p.traceProvider.Shutdown(ctx); err != nil {
+	if traceProvider != nil {
+		report.Traces = shutdownSignal(func() error {
+			return traceProvider.Shutdown(ctx)
+		})
+		if err := report.Traces.Err; err != nil {
 			errs = append(errs, fmt.Errorf("failed to shutdown tracing: %w", err))
 		}
 	}
 
+	// 按注册的逆序执行用户注册的关闭钩子，日志此时仍可用
+	p.hookMu.Lock()
+	hooks := p.shutdownHooks
+	p.shutdownHooks = nil
+	p.hookMu.Unlock()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook failed: %w", err))
+		}
+	}
+
 	// 关闭日志
 	if p.logProvider != nil {
-		if err := p.logProvider.Shutdown(); err != nil {
+		report.Logs = shutdownSignal(p.logProvider.Shutdown)
+		if err := report.Logs.Err; err != nil {
 			errs = append(errs, fmt.Errorf("failed to shutdown logging: %w", err))
 		}
 	}
 
 	if len(errs) > 0 {
-		if p.shutdownErrors != nil {
-			p.shutdownErrors.Add(ctx, int64(len(errs)))
+		if shutdownErrors != nil {
+			shutdownErrors.Add(ctx, int64(len(errs)))
 		}
-		return fmt.Errorf("errors during shutdown: %v", errs)
+		return report, fmt.Errorf("errors during shutdown: %v", errs)
 	}
-	return nil
+	return report, nil
 }
 
-// 提供对配置的访问
+// OnShutdown 注册在 Shutdown 时执行的清理函数（如关闭数据库连接池、客户端），
+// 在 trace 与 metrics 刷新并关闭之后、日志关闭之前按注册的逆序（LIFO）执行，错误汇总到 Shutdown 返回的错误中
+func (p *Provider) OnShutdown(fn func(context.Context) error) {
+	p.hookMu.Lock()
+	defer p.hookMu.Unlock()
+	p.shutdownHooks = append(p.shutdownHooks, fn)
+}
+
+// RegisterMetricCallback 在本 Provider 以服务名获取的 meter 上注册自定义观测型指标（如队列深度、缓存大小）的回调，
+// 回调随 Shutdown 一并注销；SDK 只允许在创建 instrument 的 meter 上注册回调，因此 instruments 需由 p.Meter(服务名) 创建
+// UpdateResourceAttribute 重建 provider 时，instruments 会在新 provider 上以相同选项重新创建并重新注册回调，
+// 回调中对原 instrument 的观测自动转发到新 instrument
+func (p *Provider) RegisterMetricCallback(instruments []metric.Observable, cb metric.Callback) (metric.Registration, error) {
+	// 持有读锁直到登记完成，避免与 UpdateResourceAttribute 并发时注册在即将关闭的 provider 上
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+
+	scope := p.config.ServiceName
+	reg, err := p.meter(scope).RegisterCallback(cb, instruments...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register metric callback: %w", err)
+	}
+
+	c := &metricCallback{scope: scope, instruments: instruments, cb: cb, reg: reg}
+	p.callbackMu.Lock()
+	p.callbacks = append(p.callbacks, c)
+	p.callbackMu.Unlock()
+	return &callbackRegistration{p: p, c: c}, nil
+}
+
+// PrometheusHandler 返回 Prometheus 抓取端点的 HTTP handler，未启用 Prometheus 导出器时返回 404
+// 每次请求时取当前的 provider，UpdateResourceAttribute 重建 provider 后无需重新获取
+func (p *Provider) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.configMu.RLock()
+		handler := p.metricProvider.PrometheusHandler()
+		p.configMu.RUnlock()
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// RecentSpansHandler 返回以 JSON 输出最近结束的 span 的 HTTP handler，未设置 DebugSpanBufferSize 时返回 404
+func (p *Provider) RecentSpansHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p.configMu.RLock()
+		handler := p.traceProvider.RecentSpansHandler()
+		p.configMu.RUnlock()
+		handler(w, r)
+	}
+}
+
+// DependencyGraphHandler 返回以 JSON 输出观测到的服务依赖边的 HTTP handler，未启用 EnableDependencyGraph 时返回 404
+func (p *Provider) DependencyGraphHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p.configMu.RLock()
+		handler := p.traceProvider.DependencyGraphHandler()
+		p.configMu.RUnlock()
+		handler(w, r)
+	}
+}
+
+// ForceSampleTraceID 在 ttl 内强制采样属于指定 trace 的 span，不受采样比例影响，用于事故排查时完整捕获已知 trace 的后续工作
+// 集合最多保留 1024 个 trace ID，超出时淘汰最早过期的条目；永不采样列表与 sampling.priority=0 仍然优先
+func (p *Provider) ForceSampleTraceID(id trace.TraceID, ttl time.Duration) {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	if p.traceProvider != nil && p.traceProvider.sampler != nil {
+		p.traceProvider.sampler.forceTraceID(id, ttl)
+	}
+}
+
+// SetConsoleExporter 在运行时开关控制台 span 输出，OTLP 等其他导出器不受影响
+// 导出器在 SetupTracing 时确定，因此仅当启动时 EnableConsoleExporter 为 true 时有效，否则为空操作
+func (p *Provider) SetConsoleExporter(enabled bool) {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	if p.traceProvider != nil && p.traceProvider.console != nil {
+		p.traceProvider.console.enabled.Store(enabled)
+	}
+}
+
+// Tracer 从本 Provider 自身的 TracerProvider 获取 tracer，不经过全局 provider，
+// 适用于存在多个 Provider（如测试中的隔离实例）的场景；与包级 Tracer 一样使用默认的 instrumentation scope 版本
+// 通过 UpdateResourceAttribute 重建 provider 后，此前获取的 tracer 仍绑定旧 provider，需重新获取
+func (p *Provider) Tracer(name string) trace.Tracer {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	if p.traceProvider == nil {
+		return tracenoop.NewTracerProvider().Tracer(name)
+	}
+	return scopedTracer(p.traceProvider.provider, name)
+}
+
+// Meter 从本 Provider 自身的 MeterProvider 获取 meter，不经过全局 provider；未启用指标或未配置任何指标导出器时返回 noop meter
+// 与 Tracer 相同，UpdateResourceAttribute 重建 provider 后需重新获取（经 RegisterMetricCallback 注册的观测型指标除外）
+func (p *Provider) Meter(name string) metric.Meter {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	return p.meter(name)
+}
+
+// meter 返回记录观测型 instrument 创建方式的 meter（见 RegisterMetricCallback），调用方需持有 configMu
+func (p *Provider) meter(name string) metric.Meter {
+	if p.metricProvider == nil || p.metricProvider.meterProvider == nil {
+		return metricnoop.NewMeterProvider().Meter(name)
+	}
+	return &providerMeter{Meter: scopedMeter(p.metricProvider.meterProvider, name), p: p}
+}
+
+// meterProvider 返回本 Provider 的 meter provider，未启用指标或未配置任何指标导出器时返回 noop provider，调用方需持有 configMu
+func (p *Provider) meterProvider() metric.MeterProvider {
+	if p.metricProvider == nil || p.metricProvider.meterProvider == nil {
+		return metricnoop.NewMeterProvider()
+	}
+	return p.metricProvider.meterProvider
+}
+
+// 提供对配置的访问（包含通过 WatchConfig 热更新后的值）
 func (p *Provider) Config() Config {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
 	return p.config
 }
 
-// initHealthMetrics 暴露 Provider 自观测指标
+// UpdateResourceAttribute 更新资源属性，并以新资源重建 TracerProvider/MeterProvider 替换当前 provider，
+// 旧 provider 在导出剩余数据后关闭（最长等待 OTLPExportTimeout）
+// SDK 中的资源不可变，因此这是开销较大的操作（重新建立导出连接、重新启动 runtime 指标等），
+// 仅适用于蓝绿部署颜色等低频变化，不要用于高频更新。替换后通过 Tracer/Meter/ContextWithSpan 获取的
+// tracer 与 meter 使用新 provider；替换前缓存的 tracer、meter 及其创建的 instrument 仍绑定旧 provider，
+// 旧 provider 关闭后不再产生数据。通过 RegisterMetricCallback 注册的回调、控制台输出开关与
+// ForceSampleTraceID 设置的强制采样会沿用到新 provider
+func (p *Provider) UpdateResourceAttribute(key, value string) error {
+	p.configMu.Lock()
+
+	cfg := p.config
+	attrs := make(map[string]string, len(cfg.ResourceAttributes)+1)
+	for k, v := range cfg.ResourceAttributes {
+		attrs[k] = v
+	}
+	attrs[key] = value
+	cfg.ResourceAttributes = attrs
+
+	oldTrace, oldMetric := p.traceProvider, p.metricProvider
+	prevMeterProvider := otel.GetMeterProvider()
+
+	// 先重建 metrics，trace 的自观测指标绑定到新的 meter provider
+	var metricProvider *MetricProvider
+	if cfg.EnableMetrics {
+		var err error
+		metricProvider, err = SetupMetrics(cfg)
+		if err != nil {
+			p.configMu.Unlock()
+			return fmt.Errorf("failed to rebuild metrics: %w", err)
+		}
+	}
+	p.metricProvider = metricProvider
+
+	traceProvider, err := setupTracing(cfg, p.meterProvider())
+	if err != nil {
+		// 恢复旧的 meter provider
+		if metricProvider != nil {
+			_ = metricProvider.Shutdown(context.Background())
+		}
+		p.metricProvider = oldMetric
+		otel.SetMeterProvider(prevMeterProvider)
+		p.configMu.Unlock()
+		return fmt.Errorf("failed to rebuild tracing: %w", err)
+	}
+	if oldTrace != nil {
+		// 沿用通过 SetConsoleExporter 设置的控制台输出开关
+		if oldTrace.console != nil && traceProvider.console != nil {
+			traceProvider.console.enabled.Store(oldTrace.console.enabled.Load())
+		}
+		// 沿用通过 ForceSampleTraceID 设置的强制采样
+		if oldTrace.sampler != nil && traceProvider.sampler != nil {
+			traceProvider.sampler.copyForcedTraceIDs(oldTrace.sampler)
+		}
+	}
+
+	p.config = cfg
+	p.traceProvider = traceProvider
+	// 自观测指标与用户回调绑定在本 Provider 的 meter provider 上，随旧 provider 关闭失效，需在新 provider 上重新注册
+	p.initHealthMetrics()
+	p.reregisterMetricCallbacks(p.meterProvider())
+	p.configMu.Unlock()
+
+	zap.L().Info("Resource attribute updated, telemetry providers rebuilt",
+		zap.String("key", key),
+		zap.String("value", value),
+	)
+
+	// 排空旧 provider 中尚未导出的数据
+	timeout := cfg.OTLPExportTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var errs []error
+	if oldMetric != nil {
+		if err := oldMetric.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown previous metrics: %w", err))
+		}
+	}
+	if oldTrace != nil {
+		if err := oldTrace.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown previous tracing: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("errors while draining previous providers: %v", errs)
+	}
+	return nil
+}
+
+// initHealthMetrics 在本 Provider 的 meter provider 上暴露自观测指标，调用方需持有 configMu（或 Provider 尚未发布）
+// 回调在抓取时执行、不持有 configMu，因此只引用注册时取得的配置副本
 func (p *Provider) initHealthMetrics() {
-	p.startTime = time.Now()
-	meter := otel.Meter("telemetry.provider")
+	if p.startTime.IsZero() {
+		p.startTime = time.Now()
+	}
+	startTime := p.startTime
+	serviceAttrs := []attribute.KeyValue{
+		attribute.String("service.name", p.config.ServiceName),
+		attribute.String("service.version", p.config.ServiceVersion),
+		attribute.String("environment", p.config.Environment),
+	}
+	uptimeAttrs := metric.WithAttributes(attribute.String("service.name", p.config.ServiceName))
+	meter := scopedMeter(p.meterProvider(), internalScopeName)
 
 	up, err := meter.Int64ObservableGauge("telemetry_provider_up",
 		metric.WithDescription("Telemetry provider up gauge (1=up)"),
@@ -109,11 +517,7 @@ func (p *Provider) initHealthMetrics() {
 	if err == nil {
 		p.providerUp = up
 		_, _ = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
-			o.ObserveInt64(up, 1, metric.WithAttributes(
-				attribute.String("service.name", p.config.ServiceName),
-				attribute.String("service.version", p.config.ServiceVersion),
-				attribute.String("environment", p.config.Environment),
-			))
+			o.ObserveInt64(up, 1, metric.WithAttributes(serviceAttrs...))
 			return nil
 		}, up)
 	}
@@ -129,10 +533,43 @@ func (p *Provider) initHealthMetrics() {
 		metric.WithDescription("Telemetry provider uptime in seconds"),
 		metric.WithUnit("s"),
 		metric.WithFloat64Callback(func(ctx context.Context, o metric.Float64Observer) error {
-			o.Observe(time.Since(p.startTime).Seconds(), metric.WithAttributes(
-				attribute.String("service.name", p.config.ServiceName),
-			))
+			o.Observe(time.Since(startTime).Seconds(), uptimeAttrs)
+			return nil
+		}),
+	)
+
+	// OTLP 连接状态与 partial success 中被拒绝的数据量
+	registerOTLPConnectionGauge(meter)
+	registerOTLPRejectedCounter(meter)
+
+	// 构建信息，恒为 1，用于关联部署与行为变化
+	buildAttrs := metric.WithAttributes(
+		attribute.String("version", p.config.ServiceVersion),
+		attribute.String("commit", buildCommit(p.config)),
+		attribute.String("build_date", p.config.BuildDate),
+		attribute.String("go_version", runtime.Version()),
+	)
+	_, _ = meter.Int64ObservableGauge("telemetry_build_info",
+		metric.WithDescription("Build information of the running service (always 1)"),
+		metric.WithUnit("{info}"),
+		metric.WithInt64Callback(func(ctx context.Context, o metric.Int64Observer) error {
+			o.Observe(1, buildAttrs)
 			return nil
 		}),
 	)
 }
+
+// buildCommit 返回构建提交，未配置时从构建信息的 vcs.revision 读取
+func buildCommit(cfg Config) string {
+	if cfg.BuildCommit != "" {
+		return cfg.BuildCommit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
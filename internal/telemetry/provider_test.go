@@ -0,0 +1,259 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestShutdownTwice(t *testing.T) {
+	p := newTestProvider(t, testProviderConfig())
+
+	first, err := p.ShutdownWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("first Shutdown: %v", err)
+	}
+	if !first.Traces.Flushed || !first.Metrics.Flushed || !first.Logs.Flushed {
+		t.Errorf("first Shutdown report = %+v, want all signals flushed", first)
+	}
+
+	second, err := p.ShutdownWithReport(context.Background())
+	if err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+	if second != first {
+		t.Errorf("second Shutdown report = %+v, want the first report %+v", second, first)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("third Shutdown: %v", err)
+	}
+}
+
+func TestShutdownReportFailingSignal(t *testing.T) {
+	p := newTestProvider(t, testProviderConfig())
+	exportErr := errors.New("collector unreachable")
+	p.metricProvider.cleanup = func() error { return exportErr }
+
+	report, err := p.ShutdownWithReport(context.Background())
+	if err == nil || !strings.Contains(err.Error(), exportErr.Error()) {
+		t.Errorf("ShutdownWithReport error = %v, want it to report %v", err, exportErr)
+	}
+	if report.Metrics.Flushed || !errors.Is(report.Metrics.Err, exportErr) {
+		t.Errorf("metrics result = %+v, want not flushed with %v", report.Metrics, exportErr)
+	}
+	if !report.Traces.Flushed || report.Traces.Err != nil {
+		t.Errorf("traces result = %+v, want flushed", report.Traces)
+	}
+	if !report.Logs.Flushed || report.Logs.Err != nil {
+		t.Errorf("logs result = %+v, want flushed", report.Logs)
+	}
+}
+
+func TestOnShutdownHooks(t *testing.T) {
+	p := newTestProvider(t, testProviderConfig())
+
+	var order []string
+	hook := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return err
+		}
+	}
+	p.OnShutdown(hook("db", errors.New("db pool close timed out")))
+	p.OnShutdown(hook("cache", nil))
+	p.OnShutdown(hook("queue", errors.New("queue drain failed")))
+
+	err := p.Shutdown(context.Background())
+	// 按注册的逆序执行，失败的钩子不影响后续钩子
+	if want := []string{"queue", "cache", "db"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("hooks ran in order %v, want %v", order, want)
+	}
+	if err == nil {
+		t.Fatal("Shutdown error = nil, want the hook errors")
+	}
+	for _, msg := range []string{"db pool close timed out", "queue drain failed"} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("Shutdown error = %v, want it to include %q", err, msg)
+		}
+	}
+
+	// 钩子只执行一次
+	p.Shutdown(context.Background())
+	if len(order) != 3 {
+		t.Errorf("hooks ran %d times after second Shutdown, want 3", len(order))
+	}
+}
+
+func TestRegisterMetricCallback(t *testing.T) {
+	p := newTestProvider(t, testProviderConfig())
+
+	depth, err := p.Meter(p.Config().ServiceName).Int64ObservableGauge("queue_depth")
+	if err != nil {
+		t.Fatalf("create gauge: %v", err)
+	}
+	var calls atomic.Int32
+	_, err = p.RegisterMetricCallback([]metric.Observable{depth}, func(ctx context.Context, o metric.Observer) error {
+		calls.Add(1)
+		o.ObserveInt64(depth, 42)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterMetricCallback: %v", err)
+	}
+
+	if body := scrape(p); !regexp.MustCompile(`(?m)^queue_depth\{[^}]*\} 42$`).MatchString(body) {
+		t.Fatalf("queue_depth not exported, got:\n%s", body)
+	}
+	if calls.Load() == 0 {
+		t.Fatal("callback was not invoked")
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	p.callbackMu.Lock()
+	remaining := len(p.callbacks)
+	p.callbackMu.Unlock()
+	if remaining != 0 {
+		t.Errorf("%d callbacks still registered after Shutdown", remaining)
+	}
+	before := calls.Load()
+	scrape(p)
+	if calls.Load() != before {
+		t.Error("callback invoked after Shutdown")
+	}
+}
+
+func TestUpdateResourceAttribute(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.TrackActiveSpans = true
+	p := newTestProvider(t, cfg)
+
+	depth, err := p.Meter(cfg.ServiceName).Int64ObservableGauge("queue_depth")
+	if err != nil {
+		t.Fatalf("create gauge: %v", err)
+	}
+	if _, err := p.RegisterMetricCallback([]metric.Observable{depth}, func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(depth, 7)
+		return nil
+	}); err != nil {
+		t.Fatalf("RegisterMetricCallback: %v", err)
+	}
+	forced := trace.TraceID{0xab}
+	p.ForceSampleTraceID(forced, time.Minute)
+
+	if err := p.UpdateResourceAttribute("deployment.color", "green"); err != nil {
+		t.Fatalf("UpdateResourceAttribute: %v", err)
+	}
+
+	_, span := p.Tracer("test").Start(context.Background(), "after-update")
+	span.End()
+	spans := p.traceProvider.recentSpans.snapshot()
+	if len(spans) == 0 {
+		t.Fatal("no span recorded after update")
+	}
+	last := spans[len(spans)-1]
+	if v, ok := last.Resource().Set().Value("deployment.color"); !ok || v.AsString() != "green" {
+		t.Errorf("span resource deployment.color = %q, want green", v.AsString())
+	}
+
+	// 用户回调与 trace 自观测指标都注册在新的 meter provider 上
+	body := scrape(p)
+	for _, pattern := range []string{
+		`(?m)^queue_depth\{[^}]*\} 7$`,
+		`(?m)^telemetry_sampling_decisions_total\{`,
+		`(?m)^telemetry_active_spans\{`,
+		`(?m)^telemetry_provider_up\{`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(body) {
+			t.Errorf("%s not found after update, got:\n%s", pattern, body)
+		}
+	}
+
+	if !p.traceProvider.sampler.isForcedTraceID(forced) {
+		t.Error("forced trace ID not carried over to the rebuilt sampler")
+	}
+}
+
+func TestProviderTracerAndMeterAreIndependent(t *testing.T) {
+	cfgA, cfgB := testProviderConfig(), testProviderConfig()
+	cfgA.ServiceName, cfgB.ServiceName = "provider-a", "provider-b"
+	a := newTestProvider(t, cfgA)
+	b := newTestProvider(t, cfgB)
+
+	_, span := a.Tracer("test").Start(context.Background(), "from-a")
+	span.End()
+	counter, err := a.Meter("test").Int64Counter("provider_a_events")
+	if err != nil {
+		t.Fatalf("create counter: %v", err)
+	}
+	counter.Add(context.Background(), 1)
+
+	// a 在 b 之前创建，全局 provider 已指向 b，a 的 tracer/meter 仍使用 a 自身的实例
+	if spans := a.traceProvider.recentSpans.snapshot(); len(spans) != 1 || spans[0].Name() != "from-a" {
+		t.Errorf("provider a recorded %d spans, want from-a only", len(spans))
+	}
+	if spans := b.traceProvider.recentSpans.snapshot(); len(spans) != 0 {
+		t.Errorf("provider b recorded %d spans from provider a's tracer", len(spans))
+	}
+	if !strings.Contains(scrape(a), "provider_a_events") {
+		t.Error("counter from provider a's meter missing from provider a")
+	}
+	if strings.Contains(scrape(b), "provider_a_events") {
+		t.Error("counter from provider a's meter exported by provider b")
+	}
+}
+
+func TestScrapeDuringResourceUpdate(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.EnableDependencyGraph = true
+	p := newTestProvider(t, cfg)
+	handlers := []http.Handler{p.PrometheusHandler(), p.RecentSpansHandler(), p.DependencyGraphHandler()}
+
+	// 在 -race 下运行：抓取与重建 provider 并发时不应产生数据竞争
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, h := range handlers {
+		wg.Add(1)
+		go func(h http.Handler) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				if rec.Code != http.StatusOK {
+					t.Errorf("handler returned %d, want 200", rec.Code)
+					return
+				}
+			}
+		}(h)
+	}
+	for i := 0; i < 3; i++ {
+		if err := p.UpdateResourceAttribute("deployment.color", fmt.Sprintf("color-%d", i)); err != nil {
+			t.Errorf("UpdateResourceAttribute: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	// 抓取端点在重建后读取新 provider 的注册表
+	if !strings.Contains(scrape(p), "telemetry_provider_up") {
+		t.Error("telemetry_provider_up missing after update")
+	}
+}
@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RateLimiterOption 配置限流器的选项
+type RateLimiterOption func(*RateLimiter)
+
+// WithRateLimiterName 设置限流器名称，作为 ratelimit.name 属性区分不同下游（默认 default）
+func WithRateLimiterName(name string) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.name = name
+	}
+}
+
+// RateLimiter 令牌桶限流器，被限流时向当前 span 添加 rate_limited 事件并递增 ratelimit.throttled.total 计数器
+type RateLimiter struct {
+	name      string
+	rate      float64
+	burst     float64
+	throttled metric.Int64Counter
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter 创建每秒产生 rps 个令牌、桶容量为 burst 的限流器
+// rps <= 0 表示不限流；burst < 1 时按 1 处理
+func NewRateLimiter(rps int, burst int, opts ...RateLimiterOption) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &RateLimiter{
+		name:   "default",
+		rate:   float64(rps),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	throttled, err := Meter("telemetry.ratelimit").Int64Counter("ratelimit.throttled.total",
+		metric.WithDescription("Number of requests throttled by the rate limiter"),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	l.throttled = throttled
+	return l
+}
+
+// Allow 立即判断是否允许本次请求，不等待；被拒绝时记录限流事件
+func (l *RateLimiter) Allow(ctx context.Context) bool {
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	l.refill(time.Now())
+	allowed := l.tokens >= 1
+	if allowed {
+		l.tokens--
+	}
+	l.mu.Unlock()
+
+	if !allowed {
+		l.recordThrottle(ctx, 0)
+	}
+	return allowed
+}
+
+// Wait 等待直到获得令牌或 ctx 结束；需要等待时记录限流事件，ctx 结束时归还预占的令牌并返回 ctx 的错误
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	l.refill(time.Now())
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	l.recordThrottle(ctx, wait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// refill 按经过的时间补充令牌，调用方需持有 mu
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	l.tokens += elapsed * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// recordThrottle 记录限流事件与计数，wait 为 Wait 需要等待的时长（Allow 拒绝时为 0）
+func (l *RateLimiter) recordThrottle(ctx context.Context, wait time.Duration) {
+	nameAttr := attribute.String("ratelimit.name", l.name)
+	attrs := []attribute.KeyValue{nameAttr}
+	if wait > 0 {
+		attrs = append(attrs, attribute.Float64("ratelimit.wait_ms", float64(wait)/float64(time.Millisecond)))
+	}
+	AddSpanEvent(ctx, "rate_limited", attrs...)
+
+	if l.throttled != nil {
+		l.throttled.Add(ctx, 1, metric.WithAttributes(nameAttr))
+	}
+}
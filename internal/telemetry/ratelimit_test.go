@@ -0,0 +1,143 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// throttledCount 返回 ratelimit.throttled.total 在指定限流器名称下的累计值
+func throttledCount(t *testing.T, reader sdkmetric.Reader, name string) int64 {
+	t.Helper()
+	_, m, ok := collectMetric(t, reader, "ratelimit.throttled.total")
+	if !ok {
+		return 0
+	}
+	want := attribute.NewSet(attribute.String("ratelimit.name", name))
+	for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+		if dp.Attributes.Equals(&want) {
+			return dp.Value
+		}
+	}
+	return 0
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	exporter := setupTestTracing(t)
+	reader := setupTestMetrics(t)
+	l := NewRateLimiter(1, 3, WithRateLimiterName("payments"))
+
+	ctx, span := Tracer("test").Start(context.Background(), "request")
+	for i := range 3 {
+		if !l.Allow(ctx) {
+			t.Fatalf("request %d within burst was denied", i)
+		}
+	}
+	if l.Allow(ctx) {
+		t.Error("request beyond burst was allowed")
+	}
+	span.End()
+
+	if got := throttledCount(t, reader, "payments"); got != 1 {
+		t.Errorf("ratelimit.throttled.total = %d, want 1", got)
+	}
+	if !hasSpanEvent(exporter.GetSpans(), "request", "rate_limited") {
+		t.Error("request span has no rate_limited event")
+	}
+
+	// 经过足够时间后令牌补满，但不超过桶容量
+	l.mu.Lock()
+	l.last = l.last.Add(-time.Hour)
+	l.mu.Unlock()
+	for i := range 3 {
+		if !l.Allow(context.Background()) {
+			t.Fatalf("request %d after refill was denied", i)
+		}
+	}
+	if l.Allow(context.Background()) {
+		t.Error("refill exceeded the burst size")
+	}
+}
+
+func TestRateLimiterAllowUnderLoad(t *testing.T) {
+	setupTestMetrics(t)
+	l := NewRateLimiter(1, 5)
+
+	var allowed atomic.Int64
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Allow(context.Background()) {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	// 每秒只补充 1 个令牌，测试期间最多多放行 1 个
+	if got := allowed.Load(); got < 5 || got > 6 {
+		t.Errorf("allowed %d of 100 concurrent requests, want 5 (burst)", got)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	exporter := setupTestTracing(t)
+	setupTestMetrics(t)
+	l := NewRateLimiter(100, 1)
+
+	ctx, span := Tracer("test").Start(context.Background(), "request")
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("first Wait: %v", err)
+	}
+	start := time.Now()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("second Wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("second Wait returned after %s, want about 10ms", elapsed)
+	}
+	span.End()
+	if !hasSpanEvent(exporter.GetSpans(), "request", "rate_limited") {
+		t.Error("request span has no rate_limited event")
+	}
+
+	// ctx 结束时返回其错误并归还预占的令牌
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Wait(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait with cancelled ctx = %v, want context.Canceled", err)
+	}
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.5 {
+		t.Errorf("tokens = %.2f after cancelled Wait, want the reservation returned", tokens)
+	}
+
+	if err := NewRateLimiter(0, 1).Wait(context.Background()); err != nil {
+		t.Errorf("unlimited Wait = %v, want nil", err)
+	}
+}
+
+// hasSpanEvent 判断指定名称的 span 是否记录了事件
+func hasSpanEvent(spans tracetest.SpanStubs, spanName, event string) bool {
+	span, ok := findSpan(spans, spanName)
+	if !ok {
+		return false
+	}
+	for _, e := range span.Events {
+		if e.Name == event {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// recentSpanProcessor 在内存环形缓冲中保留最近结束的 N 个 span，供调试端点查看
+// 每个 span 保留完整的属性与事件，内存占用约为 N 倍的单个 span 大小；缓冲不落盘、不导出，不能替代真正的导出
+type recentSpanProcessor struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+	next  int
+	full  bool
+}
+
+func newRecentSpanProcessor(size int) *recentSpanProcessor {
+	return &recentSpanProcessor{spans: make([]sdktrace.ReadOnlySpan, size)}
+}
+
+func (p *recentSpanProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+// OnEnd 将结束的 span 写入环形缓冲，缓冲满时覆盖最旧的 span
+func (p *recentSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.spans[p.next] = s
+	p.next = (p.next + 1) % len(p.spans)
+	if p.next == 0 {
+		p.full = true
+	}
+}
+
+func (p *recentSpanProcessor) Shutdown(context.Context) error { return nil }
+
+func (p *recentSpanProcessor) ForceFlush(context.Context) error { return nil }
+
+// snapshot 按结束顺序返回缓冲中的 span（最旧的在前）
+func (p *recentSpanProcessor) snapshot() []sdktrace.ReadOnlySpan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.full {
+		return append([]sdktrace.ReadOnlySpan(nil), p.spans[:p.next]...)
+	}
+	spans := make([]sdktrace.ReadOnlySpan, 0, len(p.spans))
+	spans = append(spans, p.spans[p.next:]...)
+	return append(spans, p.spans[:p.next]...)
+}
+
+// recentSpan RecentSpansHandler 输出的 span JSON 结构
+type recentSpan struct {
+	TraceID       string         `json:"trace_id"`
+	SpanID        string         `json:"span_id"`
+	ParentSpanID  string         `json:"parent_span_id,omitempty"`
+	Name          string         `json:"name"`
+	Kind          string         `json:"kind"`
+	StartTime     time.Time      `json:"start_time"`
+	Duration      string         `json:"duration"`
+	Status        string         `json:"status"`
+	StatusMessage string         `json:"status_message,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Events        []recentEvent  `json:"events,omitempty"`
+}
+
+// recentEvent span 事件的 JSON 结构
+type recentEvent struct {
+	Name       string         `json:"name"`
+	Time       time.Time      `json:"time"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// newRecentSpan 将 span 转换为 JSON 结构
+func newRecentSpan(s sdktrace.ReadOnlySpan) recentSpan {
+	out := recentSpan{
+		TraceID:       s.SpanContext().TraceID().String(),
+		SpanID:        s.SpanContext().SpanID().String(),
+		Name:          s.Name(),
+		Kind:          s.SpanKind().String(),
+		StartTime:     s.StartTime(),
+		Duration:      s.EndTime().Sub(s.StartTime()).String(),
+		Status:        s.Status().Code.String(),
+		StatusMessage: s.Status().Description,
+		Attributes:    attributeMap(s.Attributes()),
+	}
+	if s.Parent().IsValid() {
+		out.ParentSpanID = s.Parent().SpanID().String()
+	}
+	for _, e := range s.Events() {
+		out.Events = append(out.Events, recentEvent{
+			Name:       e.Name,
+			Time:       e.Time,
+			Attributes: attributeMap(e.Attributes),
+		})
+	}
+	return out
+}
+
+// attributeMap 将属性列表转换为 JSON 友好的 map
+func attributeMap(attrs []attribute.KeyValue) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(attrs))
+	for _, kv := range attrs {
+		m[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return m
+}
+
+// RecentSpansHandler 以 JSON 数组输出最近结束的 span（最旧的在前），未启用 DebugSpanBufferSize 时返回 404
+func (tp *TraceProvider) RecentSpansHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if tp == nil || tp.recentSpans == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		spans := tp.recentSpans.snapshot()
+		out := make([]recentSpan, 0, len(spans))
+		for _, s := range spans {
+			out = append(out, newRecentSpan(s))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}
+}
@@ -0,0 +1,172 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// reloadableConfigKeys 可在运行时重新加载的配置项，其余配置项变更后需要重启
+var reloadableConfigKeys = map[string]bool{
+	"OTEL_SAMPLING_RATIO":            true,
+	"OTEL_LOG_LEVEL":                 true,
+	"OTEL_NEVER_SAMPLE_SPAN_NAMES":   true,
+	"OTEL_OPERATION_SAMPLING_RATIOS": true,
+}
+
+// readConfigFile 读取 KEY=VALUE 格式的配置文件，键与 DefaultConfig 使用的环境变量名相同
+// 空行与 # 开头的行会被忽略，值两侧的引号会被去除
+func readConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid config line %d: %q", line, text)
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return values, nil
+}
+
+// WatchConfig 加载配置文件并在收到 SIGHUP 时重新加载，应用可安全热更新的配置：
+// 采样比例（OTEL_SAMPLING_RATIO）、按 span 名称的采样比例（OTEL_OPERATION_SAMPLING_RATIOS）、
+// 日志级别（OTEL_LOG_LEVEL）与永不采样的 span 名称（OTEL_NEVER_SAMPLE_SPAN_NAMES）；
+// 其他配置项变更时仅输出需要重启的警告。监听在 Shutdown 时停止
+func (p *Provider) WatchConfig(path string) error {
+	previous, err := p.reloadConfig(path, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.configMu.Lock()
+	if p.stopWatch != nil {
+		p.stopWatch()
+	}
+	p.stopWatch = cancel
+	p.configMu.Unlock()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				values, err := p.reloadConfig(path, previous)
+				if err != nil {
+					Logger().Error("Failed to reload config", zap.String("path", path), zap.Error(err))
+					continue
+				}
+				previous = values
+			}
+		}
+	}()
+	return nil
+}
+
+// reloadConfig 读取配置文件并应用可热更新的配置项，previous 为上一次加载的内容（首次加载时为 nil）
+func (p *Provider) reloadConfig(path string, previous map[string]string) (map[string]string, error) {
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先校验全部可热更新的配置项，避免只应用一部分
+	var (
+		ratio    float64
+		level    zapcore.Level
+		hasRatio bool
+		hasLevel bool
+	)
+	if value, ok := values["OTEL_SAMPLING_RATIO"]; ok {
+		if ratio, err = parseFloatEnv(value); err != nil {
+			return nil, fmt.Errorf("invalid OTEL_SAMPLING_RATIO %q: %w", value, err)
+		}
+		hasRatio = true
+	}
+	if value, ok := values["OTEL_LOG_LEVEL"]; ok {
+		if level, err = zapcore.ParseLevel(value); err != nil {
+			return nil, fmt.Errorf("invalid OTEL_LOG_LEVEL %q: %w", value, err)
+		}
+		hasLevel = true
+	}
+	var (
+		operationRatios    map[string]float64
+		hasOperationRatios bool
+	)
+	if value, ok := values["OTEL_OPERATION_SAMPLING_RATIOS"]; ok {
+		if operationRatios, err = parseRatios(value); err != nil {
+			return nil, fmt.Errorf("invalid OTEL_OPERATION_SAMPLING_RATIOS %q: %w", value, err)
+		}
+		hasOperationRatios = true
+	}
+	neverSample, hasNeverSample := values["OTEL_NEVER_SAMPLE_SPAN_NAMES"]
+
+	p.configMu.Lock()
+	if hasRatio && p.traceProvider != nil && p.traceProvider.sampler != nil {
+		p.traceProvider.sampler.setRatio(ratio)
+		p.config.SamplingRatio = ratio
+	}
+	if hasOperationRatios && p.traceProvider != nil && p.traceProvider.sampler != nil {
+		p.traceProvider.sampler.setOperationRatios(operationRatios)
+		p.config.OperationSamplingRatios = operationRatios
+	}
+	if hasLevel && p.logProvider != nil {
+		p.logProvider.level.SetLevel(level)
+		p.config.LogLevel = level.String()
+	}
+	if hasNeverSample && p.traceProvider != nil && p.traceProvider.sampler != nil {
+		names := splitList(neverSample)
+		p.traceProvider.sampler.setNeverSample(names)
+		p.config.NeverSampleSpanNames = names
+	}
+	p.configMu.Unlock()
+
+	// 不可热更新的配置项发生变化时提示重启
+	if previous != nil {
+		var changed []string
+		for key, value := range values {
+			if !reloadableConfigKeys[key] && previous[key] != value {
+				changed = append(changed, key)
+			}
+		}
+		for key := range previous {
+			if _, ok := values[key]; !ok && !reloadableConfigKeys[key] {
+				changed = append(changed, key)
+			}
+		}
+		if len(changed) > 0 {
+			sort.Strings(changed)
+			Logger().Warn("Config changes require a restart to take effect", zap.Strings("keys", changed))
+		}
+	}
+
+	Logger().Info("Config reloaded", zap.String("path", path))
+	return values, nil
+}
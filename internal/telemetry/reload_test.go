@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// sampled 判断在 provider 上创建的指定名称的根 span 是否被采样
+func sampled(p *Provider, name string) bool {
+	_, span := p.Tracer("reload-test").Start(context.Background(), name)
+	defer span.End()
+	return span.SpanContext().IsSampled()
+}
+
+func TestWatchConfigReloadsOperationSamplingRatios(t *testing.T) {
+	p := newTestProvider(t, testProviderConfig())
+	path := filepath.Join(t.TempDir(), "telemetry.env")
+	writeConfig := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig("OTEL_SAMPLING_RATIO=1\nOTEL_OPERATION_SAMPLING_RATIOS=healthz=0\n")
+	if err := p.WatchConfig(path); err != nil {
+		t.Fatalf("WatchConfig: %v", err)
+	}
+	if sampled(p, "healthz") || !sampled(p, "checkout") {
+		t.Fatal("initial load did not apply the per-operation ratio")
+	}
+
+	// 修改文件后发送 SIGHUP 触发重新加载
+	writeConfig("OTEL_SAMPLING_RATIO=0\nOTEL_OPERATION_SAMPLING_RATIOS=healthz=1,checkout=1\n")
+	proc, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proc.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("cannot send SIGHUP: %v", err)
+	}
+	waitFor(t, 5*time.Second, func() bool {
+		return sampled(p, "healthz") && sampled(p, "checkout") && !sampled(p, "other")
+	})
+	if got := p.Config().OperationSamplingRatios["healthz"]; got != 1 {
+		t.Errorf("Config().OperationSamplingRatios[healthz] = %v, want 1", got)
+	}
+}
+
+func TestReloadConfigRejectsInvalidOperationRatios(t *testing.T) {
+	p := newTestProvider(t, testProviderConfig())
+	path := filepath.Join(t.TempDir(), "telemetry.env")
+	if err := os.WriteFile(path, []byte("OTEL_OPERATION_SAMPLING_RATIOS=healthz=often\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.reloadConfig(path, nil); err == nil {
+		t.Error("reloadConfig accepted a non-numeric ratio")
+	}
+}
@@ -0,0 +1,219 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxDeferredSpans 单个延迟采样请求最多缓存的 span 数，超出的 span 被丢弃
+const maxDeferredSpans = 512
+
+// deferredSamplingKey 上下文中延迟采样请求的键
+type deferredSamplingKey struct{}
+
+// deferredRequest 延迟采样的请求：其中的 span 先以 RecordOnly 记录并缓存，
+// 请求的根 span（服务端 span）结束时按响应状态码决定整体导出还是丢弃
+type deferredRequest struct {
+	mu      sync.Mutex
+	root    trace.SpanID
+	hasRoot bool
+	status  int
+	spans   []sdktrace.ReadOnlySpan
+}
+
+// deferredRequestFromContext 返回上下文中的延迟采样请求
+func deferredRequestFromContext(ctx context.Context) *deferredRequest {
+	if ctx == nil {
+		return nil
+	}
+	req, _ := ctx.Value(deferredSamplingKey{}).(*deferredRequest)
+	return req
+}
+
+// WithRouteSampling 按请求路径设置服务端请求的采样比例，"*" 为未列出路径的默认比例（未设置 "*" 时交由全局采样器决定）
+// 命中比例的请求强制采样；未命中的请求中的 span 先记录并缓存，响应状态码为 5xx 或处理器 panic 时仍然导出，
+// 因此健康检查等路由可设为 0.0 而其错误始终可见。延迟决定只作用于进程内：被缓存请求发往下游的调用携带未采样标记
+func WithRouteSampling(ratios map[string]float64) HTTPOption {
+	return func(h *HTTPMiddleware) {
+		h.routeRatios = ratios
+	}
+}
+
+// routeRatio 返回路径对应的采样比例
+func (h *HTTPMiddleware) routeRatio(path string) (float64, bool) {
+	if ratio, ok := h.routeRatios[path]; ok {
+		return ratio, true
+	}
+	ratio, ok := h.routeRatios["*"]
+	return ratio, ok
+}
+
+// routeSampling 在服务端中间件外层按路由做出采样决定，未配置 WithRouteSampling 时原样返回 next
+func (h *HTTPMiddleware) routeSampling(next http.Handler) http.Handler {
+	if h.routeRatios == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ratio, ok := h.routeRatio(r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if rand.Float64() < ratio {
+			next.ServeHTTP(w, r.WithContext(WithForceSample(r.Context())))
+			return
+		}
+
+		req := &deferredRequest{}
+		ctx := context.WithValue(r.Context(), deferredSamplingKey{}, req)
+		next.ServeHTTP(&deferredResponseWriter{ResponseWriter: w, req: req}, r.WithContext(ctx))
+	})
+}
+
+// recordPanics 在 otelhttp 内层捕获处理器的 panic，在服务端 span 结束前将请求记为 500 后继续 panic，
+// 使 panic 的请求与 5xx 响应一样被导出；未配置 WithRouteSampling 时原样返回 next
+func (h *HTTPMiddleware) recordPanics(next http.Handler) http.Handler {
+	if h.routeRatios == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if req := deferredRequestFromContext(r.Context()); req != nil {
+			defer func() {
+				if v := recover(); v != nil {
+					req.setStatus(http.StatusInternalServerError)
+					panic(v)
+				}
+			}()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setStatus 记录响应状态码
+func (req *deferredRequest) setStatus(code int) {
+	req.mu.Lock()
+	req.status = code
+	req.mu.Unlock()
+}
+
+// deferredResponseWriter 在服务端 span 结束前记录响应状态码，并透传 Flush 与 Hijack
+type deferredResponseWriter struct {
+	http.ResponseWriter
+	req *deferredRequest
+}
+
+func (rw *deferredResponseWriter) WriteHeader(code int) {
+	rw.req.setStatus(code)
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Flush 透传给底层 ResponseWriter，用于流式响应（如 SSE）
+func (rw *deferredResponseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 透传给底层 ResponseWriter，用于 WebSocket 等协议升级
+func (rw *deferredResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	return hj.Hijack()
+}
+
+// Unwrap 返回底层 ResponseWriter，供 http.ResponseController 使用
+func (rw *deferredResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// routeSamplingProcessor 缓存延迟采样请求中的 span，请求以 5xx 结束时将其标记为已采样后转发给下游处理器
+type routeSamplingProcessor struct {
+	next sdktrace.SpanProcessor
+	// 延迟采样请求中尚未结束的 span，按 span ID 索引
+	requests sync.Map
+	// requests 中的 span 数，为 0 时 OnEnd 跳过查找
+	pending atomic.Int64
+}
+
+func newRouteSamplingProcessor(next sdktrace.SpanProcessor) *routeSamplingProcessor {
+	return &routeSamplingProcessor{next: next}
+}
+
+// OnStart 登记延迟采样请求中的 span，请求中第一个开始的 span（服务端 span）作为根
+func (p *routeSamplingProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if req := deferredRequestFromContext(parent); req != nil {
+		id := s.SpanContext().SpanID()
+		req.mu.Lock()
+		if !req.hasRoot {
+			req.root, req.hasRoot = id, true
+		}
+		req.mu.Unlock()
+		p.requests.Store(id, req)
+		p.pending.Add(1)
+	}
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd 缓存延迟采样请求中的 span，根 span 结束时决定是否导出
+func (p *routeSamplingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if p.pending.Load() > 0 {
+		if v, ok := p.requests.LoadAndDelete(s.SpanContext().SpanID()); ok {
+			p.pending.Add(-1)
+			p.endDeferred(v.(*deferredRequest), s)
+			return
+		}
+	}
+	p.next.OnEnd(s)
+}
+
+// endDeferred 缓存非根 span；根 span 结束时若响应为 5xx（含处理器 panic）则转发全部缓存的 span，否则丢弃
+func (p *routeSamplingProcessor) endDeferred(req *deferredRequest, s sdktrace.ReadOnlySpan) {
+	req.mu.Lock()
+	if s.SpanContext().SpanID() != req.root {
+		if len(req.spans) < maxDeferredSpans {
+			req.spans = append(req.spans, s)
+		}
+		req.mu.Unlock()
+		return
+	}
+	spans, failed := req.spans, req.status >= http.StatusInternalServerError
+	req.spans = nil
+	req.mu.Unlock()
+
+	if !failed {
+		return
+	}
+	for _, buffered := range spans {
+		p.next.OnEnd(sampledSpan{buffered})
+	}
+	p.next.OnEnd(sampledSpan{s})
+}
+
+func (p *routeSamplingProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *routeSamplingProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// sampledSpan 将 RecordOnly 的 span 标记为已采样，使批处理器导出它
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+func (s sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
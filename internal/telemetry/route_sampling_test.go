@@ -0,0 +1,114 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// routeSampledHandler 以 /healthz 采样比例为 0 的中间件包装 handler，span 同步导出到内存
+func routeSampledHandler(t *testing.T, handler http.HandlerFunc) (http.Handler, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	setupTestTracing(t,
+		sdktrace.WithSampler(newSampler(Config{SamplingRatio: 1}, metricnoop.Meter{})),
+		sdktrace.WithSpanProcessor(newRouteSamplingProcessor(sdktrace.NewSimpleSpanProcessor(exporter))),
+	)
+	h := NewHTTPMiddlewareWithOptions("route-test", WithRouteSampling(map[string]float64{"/healthz": 0}))
+	return h.Handler(handler), exporter
+}
+
+// childSpanHandler 创建一个子 span 后以 status 响应
+func childSpanHandler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := Tracer("route-test").Start(r.Context(), "check-db")
+		span.End()
+		w.WriteHeader(status)
+	}
+}
+
+func TestRouteSamplingDropsSuccessfulRequests(t *testing.T) {
+	handler, exporter := routeSampledHandler(t, childSpanHandler(http.StatusOK))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if spans := exporter.GetSpans(); len(spans) != 0 {
+		t.Errorf("exported %d spans for a successful health check, want 0", len(spans))
+	}
+
+	// 未配置比例的路由交由全局采样器决定
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if spans := exporter.GetSpans(); len(spans) != 2 {
+		t.Errorf("exported %d spans for an unlisted route, want 2", len(spans))
+	}
+}
+
+func TestRouteSamplingCapturesServerErrors(t *testing.T) {
+	handler, exporter := routeSampledHandler(t, childSpanHandler(http.StatusInternalServerError))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans for a failed health check, want 2", len(spans))
+	}
+	for _, s := range spans {
+		if !s.SpanContext.IsSampled() {
+			t.Errorf("span %q exported without the sampled flag", s.Name)
+		}
+	}
+}
+
+func TestRouteSamplingCapturesPanics(t *testing.T) {
+	handler, exporter := routeSampledHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		_, span := Tracer("route-test").Start(r.Context(), "check-db")
+		span.End()
+		panic("boom")
+	})
+
+	func() {
+		defer func() {
+			if v := recover(); v != "boom" {
+				t.Errorf("recovered %v, want the handler panic", v)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	}()
+
+	if spans := exporter.GetSpans(); len(spans) != 2 {
+		t.Errorf("exported %d spans for a panicking health check, want 2", len(spans))
+	}
+}
+
+func TestRouteSamplingForwardsFlushAndHijack(t *testing.T) {
+	handler, _ := routeSampledHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Error("response writer does not implement http.Hijacker")
+		}
+		w.(http.Flusher).Flush()
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if !rec.Flushed {
+		t.Error("Flush was not forwarded to the underlying response writer")
+	}
+}
+
+func TestRouteSamplingProcessorWithoutDeferredRequests(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	p := newRouteSamplingProcessor(sdktrace.NewSimpleSpanProcessor(exporter))
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p))
+	defer tp.Shutdown(context.Background())
+
+	_, span := tp.Tracer("test").Start(context.Background(), "plain")
+	span.End()
+	if p.pending.Load() != 0 {
+		t.Errorf("pending = %d, want 0", p.pending.Load())
+	}
+	if len(exporter.GetSpans()) != 1 {
+		t.Errorf("exported %d spans, want 1", len(exporter.GetSpans()))
+	}
+}
@@ -0,0 +1,347 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PredicateOp 属性谓词的比较方式
+type PredicateOp string
+
+const (
+	// PredicateGreaterThan 属性值大于给定值
+	PredicateGreaterThan PredicateOp = "gt"
+	// PredicateLessThan 属性值小于给定值
+	PredicateLessThan PredicateOp = "lt"
+	// PredicateEqual 属性值等于给定值
+	PredicateEqual PredicateOp = "eq"
+)
+
+// AttributePredicate 基于 span 起始属性的采样谓词，命中时强制采样
+type AttributePredicate struct {
+	// 属性键
+	Key string
+	// 比较方式
+	Op PredicateOp
+	// 比较的目标值（数值类型按数值比较，字符串按字典序比较）
+	Value attribute.Value
+}
+
+// Match 判断属性集合中是否存在满足谓词的属性
+func (p AttributePredicate) Match(attrs []attribute.KeyValue) bool {
+	for _, kv := range attrs {
+		if string(kv.Key) != p.Key {
+			continue
+		}
+		if cmp, ok := compareAttributeValues(kv.Value, p.Value); ok {
+			switch p.Op {
+			case PredicateGreaterThan:
+				return cmp > 0
+			case PredicateLessThan:
+				return cmp < 0
+			case PredicateEqual:
+				return cmp == 0
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// compareAttributeValues 比较两个属性值，类型不可比较时返回 false
+func compareAttributeValues(a, b attribute.Value) (int, bool) {
+	if af, ok := numericValue(a); ok {
+		bf, ok := numericValue(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af > bf:
+			return 1, true
+		case af < bf:
+			return -1, true
+		}
+		return 0, true
+	}
+
+	switch a.Type() {
+	case attribute.STRING:
+		if b.Type() != attribute.STRING {
+			return 0, false
+		}
+		return strings.Compare(a.AsString(), b.AsString()), true
+	case attribute.BOOL:
+		if b.Type() != attribute.BOOL || a.AsBool() != b.AsBool() {
+			return 0, false
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// numericValue 将数值类型的属性值转换为 float64
+func numericValue(v attribute.Value) (float64, bool) {
+	switch v.Type() {
+	case attribute.INT64:
+		return float64(v.AsInt64()), true
+	case attribute.FLOAT64:
+		return v.AsFloat64(), true
+	}
+	return 0, false
+}
+
+// forceSampleKey 上下文中强制采样标记的键
+type forceSampleKey struct{}
+
+// WithForceSample 标记上下文，之后在该上下文（及其派生上下文）中创建的 span 都会被采样，不受采样比例影响
+// 适用于调试会话等入口处已知需要完整追踪的请求；标记仅在进程内生效，不随请求传播
+func WithForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSampleKey{}, true)
+}
+
+// IsForceSampled 判断上下文是否被标记为强制采样
+func IsForceSampled(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceSampleKey{}).(bool)
+	return forced
+}
+
+// maxForcedTraceIDs 强制采样的 trace ID 集合容量上限，超出时淘汰最早过期的条目
+const maxForcedTraceIDs = 1024
+
+// sampler 在基础比例采样之上叠加自定义采样规则
+// 基础采样器、按 span 名称的采样器与永不采样列表可在运行时替换（见 Provider.WatchConfig）
+type sampler struct {
+	base        atomic.Pointer[sdktrace.Sampler]
+	operations  atomic.Pointer[map[string]sdktrace.Sampler]
+	parentBased bool
+	predicates  []AttributePredicate
+	neverSample atomic.Pointer[map[string]bool]
+	decisions   metric.Int64Counter
+
+	// forced 强制采样的 trace ID 及其过期时间（见 Provider.ForceSampleTraceID），forcedCount 为空时跳过加锁
+	forcedMu    sync.Mutex
+	forced      map[trace.TraceID]time.Time
+	forcedCount atomic.Int32
+}
+
+// samplingDecisionAttrs 各采样决定对应的指标属性，预先构造以避免每次采样时分配
+var samplingDecisionAttrs = map[sdktrace.SamplingDecision]metric.AddOption{
+	sdktrace.RecordAndSample: metric.WithAttributes(attribute.String("decision", "record_and_sample")),
+	sdktrace.RecordOnly:      metric.WithAttributes(attribute.String("decision", "record_only")),
+	sdktrace.Drop:            metric.WithAttributes(attribute.String("decision", "drop")),
+}
+
+// newSampler 根据配置创建采样器，采样决定计数记录到 meter
+func newSampler(cfg Config, meter metric.Meter) *sampler {
+	s := &sampler{parentBased: cfg.ParentBasedSampling, predicates: cfg.SamplePredicates}
+	s.setRatio(cfg.SamplingRatio)
+	s.setOperationRatios(cfg.OperationSamplingRatios)
+	s.setNeverSample(cfg.NeverSampleSpanNames)
+
+	decisions, err := meter.Int64Counter("telemetry_sampling_decisions_total",
+		metric.WithDescription("Number of trace sampling decisions by outcome"),
+		metric.WithUnit("{decision}"),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	s.decisions = decisions
+	return s
+}
+
+// setRatio 替换基础比例采样器
+func (s *sampler) setRatio(ratio float64) {
+	base := s.ratioSampler(ratio)
+	s.base.Store(&base)
+}
+
+// setOperationRatios 替换按 span 名称设置的比例采样器
+func (s *sampler) setOperationRatios(ratios map[string]float64) {
+	operations := make(map[string]sdktrace.Sampler, len(ratios))
+	for name, ratio := range ratios {
+		operations[name] = s.ratioSampler(ratio)
+	}
+	s.operations.Store(&operations)
+}
+
+// ratioSampler 返回按比例采样的采样器，启用 ParentBasedSampling 时仅作用于根 span
+func (s *sampler) ratioSampler(ratio float64) sdktrace.Sampler {
+	var base sdktrace.Sampler
+	if ratio >= 1.0 {
+		base = sdktrace.AlwaysSample()
+	} else if ratio <= 0.0 {
+		base = sdktrace.NeverSample()
+	} else {
+		base = sdktrace.TraceIDRatioBased(ratio)
+	}
+	if s.parentBased {
+		base = sdktrace.ParentBased(base)
+	}
+	return base
+}
+
+// setNeverSample 替换永不采样的 span 名称列表
+func (s *sampler) setNeverSample(names []string) {
+	neverSample := make(map[string]bool, len(names))
+	for _, name := range names {
+		neverSample[name] = true
+	}
+	s.neverSample.Store(&neverSample)
+}
+
+// forceTraceID 在 ttl 内强制采样属于该 trace 的 span
+func (s *sampler) forceTraceID(id trace.TraceID, ttl time.Duration) {
+	s.forcedMu.Lock()
+	defer s.forcedMu.Unlock()
+
+	now := time.Now()
+	if s.forced == nil {
+		s.forced = make(map[trace.TraceID]time.Time)
+	}
+	for forcedID, expiry := range s.forced {
+		if now.After(expiry) {
+			delete(s.forced, forcedID)
+		}
+	}
+	if _, exists := s.forced[id]; !exists && len(s.forced) >= maxForcedTraceIDs {
+		var (
+			oldestID     trace.TraceID
+			oldestExpiry time.Time
+		)
+		for forcedID, expiry := range s.forced {
+			if oldestExpiry.IsZero() || expiry.Before(oldestExpiry) {
+				oldestID, oldestExpiry = forcedID, expiry
+			}
+		}
+		delete(s.forced, oldestID)
+	}
+	s.forced[id] = now.Add(ttl)
+	s.forcedCount.Store(int32(len(s.forced)))
+}
+
+// copyForcedTraceIDs 沿用另一个采样器中仍在强制采样期内的 trace ID（用于重建 provider 时）
+func (s *sampler) copyForcedTraceIDs(from *sampler) {
+	from.forcedMu.Lock()
+	forced := make(map[trace.TraceID]time.Time, len(from.forced))
+	for id, expiry := range from.forced {
+		forced[id] = expiry
+	}
+	from.forcedMu.Unlock()
+
+	s.forcedMu.Lock()
+	defer s.forcedMu.Unlock()
+	s.forced = forced
+	s.forcedCount.Store(int32(len(forced)))
+}
+
+// isForcedTraceID 判断 trace ID 是否处于强制采样期内，过期的条目在此时清除
+func (s *sampler) isForcedTraceID(id trace.TraceID) bool {
+	if s.forcedCount.Load() == 0 {
+		return false
+	}
+
+	s.forcedMu.Lock()
+	defer s.forcedMu.Unlock()
+
+	expiry, ok := s.forced[id]
+	if ok && time.Now().After(expiry) {
+		delete(s.forced, id)
+		s.forcedCount.Store(int32(len(s.forced)))
+		return false
+	}
+	return ok
+}
+
+// ShouldSample 做出采样决定，并按决定结果递增 telemetry_sampling_decisions_total，
+// 用于对比实际采样率与配置的比例（包含父 span 决定与强制采样的影响）
+func (s *sampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.decide(p)
+	if s.decisions != nil {
+		s.decisions.Add(p.ParentContext, 1, samplingDecisionAttrs[result.Decision])
+	}
+	return result
+}
+
+// decide 依次应用自定义采样规则，均未命中时按 span 名称的采样比例（见 Config.OperationSamplingRatios）或基础采样器决定：
+// 名称在永不采样列表中的 span 直接丢弃；起始属性 sampling.priority（OpenTracing 约定）>= 1 时强制采样、为 0 时丢弃；
+// 上下文标记为强制采样（见 WithForceSample）、trace ID 处于强制采样期内（见 Provider.ForceSampleTraceID）或任一谓词命中时强制采样；
+// 按路由延迟采样的请求（见 WithRouteSampling）中的 span 只记录不采样
+// sampling.priority 先于基础采样器生效，因此会覆盖 parentbased_* 采样器沿用的父 span 决定
+func (s *sampler) decide(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if (*s.neverSample.Load())[p.Name] {
+		return drop(p)
+	}
+
+	if priority, ok := samplingPriority(p.Attributes); ok {
+		if priority >= 1 {
+			return recordAndSample(p)
+		}
+		return drop(p)
+	}
+
+	if IsForceSampled(p.ParentContext) || s.isForcedTraceID(p.TraceID) {
+		return recordAndSample(p)
+	}
+
+	for _, predicate := range s.predicates {
+		if predicate.Match(p.Attributes) {
+			return recordAndSample(p)
+		}
+	}
+
+	// 按路由延迟采样的请求先记录不采样，请求结束时再决定是否导出（见 WithRouteSampling）
+	if deferredRequestFromContext(p.ParentContext) != nil {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordOnly,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	if operation, ok := (*s.operations.Load())[p.Name]; ok {
+		return operation.ShouldSample(p)
+	}
+	return (*s.base.Load()).ShouldSample(p)
+}
+
+// Description 返回采样器描述
+func (s *sampler) Description() string {
+	return fmt.Sprintf("OptlSampler{base=%s,operations=%d,predicates=%d,neverSample=%d}", (*s.base.Load()).Description(), len(*s.operations.Load()), len(s.predicates), len(*s.neverSample.Load()))
+}
+
+// samplingPriorityKey OpenTracing 约定的采样优先级属性
+const samplingPriorityKey = attribute.Key("sampling.priority")
+
+// samplingPriority 返回起始属性中数值类型的 sampling.priority
+func samplingPriority(attrs []attribute.KeyValue) (float64, bool) {
+	for _, kv := range attrs {
+		if kv.Key == samplingPriorityKey {
+			return numericValue(kv.Value)
+		}
+	}
+	return 0, false
+}
+
+// drop 返回保留父级 tracestate 的丢弃结果
+func drop(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.Drop,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+// recordAndSample 返回保留父级 tracestate 的采样结果
+func recordAndSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
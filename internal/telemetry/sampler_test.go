@@ -0,0 +1,201 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sampleDecision 以给定名称与起始属性向采样器请求根 span 的采样决定
+func sampleDecision(s *sampler, name string, attrs ...attribute.KeyValue) sdktrace.SamplingDecision {
+	return s.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       trace.TraceID{1},
+		Name:          name,
+		Attributes:    attrs,
+	}).Decision
+}
+
+func TestSamplePredicates(t *testing.T) {
+	s := newSampler(Config{
+		SamplingRatio: 0,
+		SamplePredicates: []AttributePredicate{
+			{Key: "order.amount", Op: PredicateGreaterThan, Value: attribute.Float64Value(1000)},
+			{Key: "customer.tier", Op: PredicateEqual, Value: attribute.StringValue("gold")},
+		},
+	}, metricnoop.Meter{})
+
+	tests := []struct {
+		name  string
+		attrs []attribute.KeyValue
+		want  sdktrace.SamplingDecision
+	}{
+		{"numeric above threshold", []attribute.KeyValue{attribute.Int("order.amount", 1500)}, sdktrace.RecordAndSample},
+		{"numeric at threshold", []attribute.KeyValue{attribute.Float64("order.amount", 1000)}, sdktrace.Drop},
+		{"numeric below threshold", []attribute.KeyValue{attribute.Int("order.amount", 10)}, sdktrace.Drop},
+		{"string equal", []attribute.KeyValue{attribute.String("customer.tier", "gold")}, sdktrace.RecordAndSample},
+		{"string different", []attribute.KeyValue{attribute.String("customer.tier", "silver")}, sdktrace.Drop},
+		{"type mismatch", []attribute.KeyValue{attribute.String("order.amount", "5000")}, sdktrace.Drop},
+		{"no attributes", nil, sdktrace.Drop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sampleDecision(s, "order", tt.attrs...); got != tt.want {
+				t.Errorf("decision = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributePredicateStringOrdering(t *testing.T) {
+	p := AttributePredicate{Key: "region", Op: PredicateLessThan, Value: attribute.StringValue("m")}
+	if !p.Match([]attribute.KeyValue{attribute.String("region", "eu-west")}) {
+		t.Error("eu-west < m did not match")
+	}
+	if p.Match([]attribute.KeyValue{attribute.String("region", "us-east")}) {
+		t.Error("us-east < m matched")
+	}
+}
+
+func TestNeverSampleSpanNames(t *testing.T) {
+	s := newSampler(Config{
+		SamplingRatio:        1,
+		NeverSampleSpanNames: []string{"cache.refresh"},
+		SamplePredicates:     []AttributePredicate{{Key: "debug", Op: PredicateEqual, Value: attribute.BoolValue(true)}},
+	}, metricnoop.Meter{})
+
+	if got := sampleDecision(s, "cache.refresh"); got != sdktrace.Drop {
+		t.Errorf("listed span decision = %v, want Drop", got)
+	}
+	// 永不采样列表优先于强制采样的谓词
+	if got := sampleDecision(s, "cache.refresh", attribute.Bool("debug", true)); got != sdktrace.Drop {
+		t.Errorf("listed span with matching predicate decision = %v, want Drop", got)
+	}
+	if got := sampleDecision(s, "checkout"); got != sdktrace.RecordAndSample {
+		t.Errorf("unlisted span decision = %v, want the configured sampler's RecordAndSample", got)
+	}
+
+	// 运行时替换列表
+	s.setNeverSample([]string{"checkout"})
+	if sampleDecision(s, "cache.refresh") != sdktrace.RecordAndSample || sampleDecision(s, "checkout") != sdktrace.Drop {
+		t.Error("setNeverSample did not replace the list")
+	}
+}
+
+func TestSamplingDecisionsMetric(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer mp.Shutdown(context.Background())
+	s := newSampler(Config{SamplingRatio: 0, NeverSampleSpanNames: []string{"health"}}, mp.Meter(internalScopeName))
+
+	sampleDecision(s, "checkout")
+	sampleDecision(s, "health")
+	sampleDecision(s, "checkout", attribute.Int("sampling.priority", 1))
+	s.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: context.WithValue(context.Background(), deferredSamplingKey{}, &deferredRequest{}),
+		TraceID:       trace.TraceID{1},
+		Name:          "deferred",
+	})
+
+	_, m, ok := collectMetric(t, reader, "telemetry_sampling_decisions_total")
+	if !ok {
+		t.Fatal("telemetry_sampling_decisions_total not collected")
+	}
+	got := map[string]int64{}
+	for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+		decision, _ := point.Attributes.Value("decision")
+		got[decision.AsString()] = point.Value
+	}
+	want := map[string]int64{"drop": 2, "record_and_sample": 1, "record_only": 1}
+	for decision, count := range want {
+		if got[decision] != count {
+			t.Errorf("decision %s = %d, want %d (all: %v)", decision, got[decision], count, got)
+		}
+	}
+}
+
+func TestForceSampleTraceID(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.SamplingRatio = 0
+	p := newTestProvider(t, cfg)
+
+	forced := trace.TraceID{0xab}
+	p.ForceSampleTraceID(forced, time.Minute)
+
+	// 强制采样的 trace 中的 span 不受采样比例影响，其他 trace 仍按比例丢弃
+	remote := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: forced,
+		SpanID:  trace.SpanID{1},
+		Remote:  true,
+	}))
+	_, span := p.Tracer("test").Start(remote, "continuation")
+	span.End()
+	if !span.SpanContext().IsSampled() || span.SpanContext().TraceID() != forced {
+		t.Errorf("span in forced trace sampled = %v, want true", span.SpanContext().IsSampled())
+	}
+	_, other := p.Tracer("test").Start(context.Background(), "unrelated")
+	other.End()
+	if other.SpanContext().IsSampled() {
+		t.Error("span in an unrelated trace was sampled with ratio 0")
+	}
+}
+
+func TestForcedTraceIDsExpireAndAreBounded(t *testing.T) {
+	s := newSampler(Config{}, metricnoop.Meter{})
+
+	s.forceTraceID(trace.TraceID{1}, -time.Second)
+	if s.isForcedTraceID(trace.TraceID{1}) {
+		t.Error("expired trace ID still forced")
+	}
+
+	id := func(i int) trace.TraceID { return trace.TraceID{byte(i >> 8), byte(i), 1} }
+	for i := 0; i < maxForcedTraceIDs+10; i++ {
+		s.forceTraceID(id(i), time.Minute+time.Duration(i)*time.Millisecond)
+	}
+	if n := s.forcedCount.Load(); n != maxForcedTraceIDs {
+		t.Errorf("forced set size = %d, want the %d bound", n, maxForcedTraceIDs)
+	}
+	// 淘汰最早过期的条目
+	if s.isForcedTraceID(id(0)) {
+		t.Error("earliest-expiring trace ID was not evicted")
+	}
+	if !s.isForcedTraceID(id(maxForcedTraceIDs + 9)) {
+		t.Error("most recently forced trace ID missing")
+	}
+}
+
+func TestWithForceSampleAtRatioZero(t *testing.T) {
+	for _, parentBased := range []bool{false, true} {
+		cfg := testProviderConfig()
+		cfg.SamplingRatio = 0
+		cfg.ParentBasedSampling = parentBased
+		p := newTestProvider(t, cfg)
+		tracer := p.Tracer("test")
+
+		ctx := WithForceSample(context.Background())
+		if !IsForceSampled(ctx) {
+			t.Fatal("IsForceSampled = false after WithForceSample")
+		}
+		ctx, root := tracer.Start(ctx, "forced")
+		_, child := tracer.Start(ctx, "forced-child")
+		child.End()
+		root.End()
+		if !root.SpanContext().IsSampled() || !child.SpanContext().IsSampled() {
+			t.Errorf("parentBased=%v: forced root sampled = %v, child sampled = %v, want both sampled",
+				parentBased, root.SpanContext().IsSampled(), child.SpanContext().IsSampled())
+		}
+
+		_, other := tracer.Start(context.Background(), "unforced")
+		other.End()
+		if other.SpanContext().IsSampled() {
+			t.Errorf("parentBased=%v: unforced span was sampled with ratio 0", parentBased)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/log"
+	apimetric "go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// internalScopeName 本包自身产生的遥测（采样决定、活跃 span、导出延迟等指标及 span 事件日志）使用的 instrumentation scope 名称
+const internalScopeName = "telemetry.provider"
+
+// defaultScopeVersion Tracer/Meter 默认使用的 instrumentation scope 版本
+var defaultScopeVersion atomic.Value
+
+func init() {
+	defaultScopeVersion.Store("")
+}
+
+// SetDefaultInstrumentationVersion 设置 Tracer/Meter 及 ContextWithSpan 等函数默认使用的 instrumentation scope 版本
+func SetDefaultInstrumentationVersion(version string) {
+	defaultScopeVersion.Store(version)
+}
+
+// TracerVersioned 获取带版本与 semconv schema URL 的 tracer，便于后端将 span 归属到具体的库版本
+func TracerVersioned(name, version string) trace.Tracer {
+	return otel.Tracer(name,
+		trace.WithInstrumentationVersion(version),
+		trace.WithSchemaURL(semconv.SchemaURL),
+	)
+}
+
+// MeterVersioned 获取带版本与 semconv schema URL 的 meter
+func MeterVersioned(name, version string) apimetric.Meter {
+	return otel.Meter(name,
+		apimetric.WithInstrumentationVersion(version),
+		apimetric.WithSchemaURL(semconv.SchemaURL),
+	)
+}
+
+// scopedTracer 从指定 provider 获取使用默认 instrumentation scope 版本与 semconv schema URL 的 tracer
+func scopedTracer(tp trace.TracerProvider, name string) trace.Tracer {
+	return tp.Tracer(name,
+		trace.WithInstrumentationVersion(defaultScopeVersion.Load().(string)),
+		trace.WithSchemaURL(semconv.SchemaURL),
+	)
+}
+
+// scopedMeter 从指定 provider 获取使用默认 instrumentation scope 版本与 semconv schema URL 的 meter
+// SDK 按完整的 scope（名称、版本、schema URL）缓存 meter，观测型 instrument 只能与创建它的 meter 一起注册回调，
+// 因此同一用途的 instrument 与回调注册都应经由此函数获取 meter
+func scopedMeter(mp apimetric.MeterProvider, name string) apimetric.Meter {
+	return mp.Meter(name,
+		apimetric.WithInstrumentationVersion(defaultScopeVersion.Load().(string)),
+		apimetric.WithSchemaURL(semconv.SchemaURL),
+	)
+}
+
+// scopedLogger 从指定 provider 获取使用默认 instrumentation scope 版本与 semconv schema URL 的 logger
+func scopedLogger(lp log.LoggerProvider, name string) log.Logger {
+	return lp.Logger(name,
+		log.WithInstrumentationVersion(defaultScopeVersion.Load().(string)),
+		log.WithSchemaURL(semconv.SchemaURL),
+	)
+}
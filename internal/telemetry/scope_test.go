@@ -0,0 +1,99 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInstrumentationScopeVersion(t *testing.T) {
+	SetDefaultInstrumentationVersion("1.2.3")
+	t.Cleanup(func() { SetDefaultInstrumentationVersion("") })
+	spans := setupTestTracing(t)
+	reader := setupTestMetrics(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	h := NewHTTPMiddleware("scope-test")
+	resp, err := h.ClientWithMetrics().Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	h.WrapHandler("wrapped", func(w http.ResponseWriter, r *http.Request) {})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	span, ok := findSpan(spans.GetSpans(), "wrapped")
+	if !ok {
+		t.Fatal("span from WrapHandler not exported")
+	}
+	if got := span.InstrumentationScope; got.Name != "scope-test" || got.Version != "1.2.3" || got.SchemaURL != semconv.SchemaURL {
+		t.Errorf("span scope = %+v, want scope-test@1.2.3 with schema %s", got, semconv.SchemaURL)
+	}
+
+	scope, _, ok := collectMetric(t, reader, "http.client.requests")
+	if !ok {
+		t.Fatal("http.client.requests not collected")
+	}
+	if scope.Scope.Name != "scope-test" || scope.Scope.Version != "1.2.3" || scope.Scope.SchemaURL != semconv.SchemaURL {
+		t.Errorf("client metric scope = %+v, want scope-test@1.2.3", scope.Scope)
+	}
+
+	s := newSampler(Config{SamplingRatio: 1}, Meter(internalScopeName))
+	s.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), TraceID: trace.TraceID{1}, Name: "op"})
+	scope, _, ok = collectMetric(t, reader, "telemetry_sampling_decisions_total")
+	if !ok {
+		t.Fatal("telemetry_sampling_decisions_total not collected")
+	}
+	if scope.Scope.Name != internalScopeName || scope.Scope.Version != "1.2.3" || scope.Scope.SchemaURL != semconv.SchemaURL {
+		t.Errorf("internal metric scope = %+v, want %s@1.2.3", scope.Scope, internalScopeName)
+	}
+}
+
+func TestTracerNameScope(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	setupTestTracing(t, sdktrace.WithSpanProcessor(recorder))
+	SetDefaultTracerName("default-scope")
+	t.Cleanup(func() { SetDefaultTracerName("") })
+
+	ctx := context.Background()
+	if got := TracerName(ctx); got != "default-scope" {
+		t.Errorf("TracerName without context value = %q, want default-scope", got)
+	}
+	_ = WithSpan(ctx, "default", func(context.Context) error { return nil })
+
+	// ContextWithSpanNamed 的子 span 沿用同一 scope
+	namedCtx, named := ContextWithSpanNamed(ctx, "component-x", "named")
+	if got := TracerName(namedCtx); got != "component-x" {
+		t.Errorf("TracerName after ContextWithSpanNamed = %q, want component-x", got)
+	}
+	_, child := ContextWithSpan(namedCtx, "named-child")
+	child.End()
+	named.End()
+
+	// 上下文中的名称优先于默认名称，GoWithSpan 同样生效
+	scopedCtx := WithTracerName(ctx, "ctx-scope")
+	_ = GoWithSpan(scopedCtx, "goroutine", func(context.Context) error { return nil })
+
+	want := map[string]string{
+		"default":     "default-scope",
+		"named":       "component-x",
+		"named-child": "component-x",
+		"goroutine":   "ctx-scope",
+	}
+	ended := recorder.Ended()
+	if len(ended) != len(want) {
+		t.Fatalf("recorded %d spans, want %d", len(ended), len(want))
+	}
+	for _, span := range ended {
+		if got := span.InstrumentationScope().Name; got != want[span.Name()] {
+			t.Errorf("span %s scope = %q, want %q", span.Name(), got, want[span.Name()])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// decodeLogLines 将 JSON 日志逐行解码
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+// testSpanContext 返回固定 ID 的有效 span 上下文
+func testSpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02},
+		SpanID:     trace.SpanID{0x03},
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+func TestSlogHandlerInjectsTraceContext(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+
+	logger := slog.New(SlogHandler()).With("component", "billing").WithGroup("req")
+	sc := testSpanContext()
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	logger.InfoContext(ctx, "charged", "amount", 42)
+	logger.WarnContext(context.Background(), "no span")
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	withSpan := lines[0]
+	if withSpan["trace_id"] != sc.TraceID().String() || withSpan["span_id"] != sc.SpanID().String() {
+		t.Errorf("trace_id/span_id = %v/%v, want %s/%s", withSpan["trace_id"], withSpan["span_id"], sc.TraceID(), sc.SpanID())
+	}
+	if withSpan["component"] != "billing" || withSpan["req.amount"] != float64(42) {
+		t.Errorf("attributes = %v, want component and req.amount", withSpan)
+	}
+	if withSpan["level"] != "info" || withSpan["msg"] != "charged" {
+		t.Errorf("level/msg = %v/%v, want info/charged", withSpan["level"], withSpan["msg"])
+	}
+
+	noSpan := lines[1]
+	if _, ok := noSpan["trace_id"]; ok {
+		t.Errorf("record without a span has trace_id %v", noSpan["trace_id"])
+	}
+	if noSpan["level"] != "warn" {
+		t.Errorf("level = %v, want warn", noSpan["level"])
+	}
+}
+
+func TestSlogWith(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewJSONHandler(&buf, nil))
+	sc := testSpanContext()
+
+	SlogWith(trace.ContextWithSpanContext(context.Background(), sc), base).Info("with span")
+	// 无效的 span 上下文原样返回 logger
+	if got := SlogWith(context.Background(), base); got != base {
+		t.Error("SlogWith without a span did not return the logger unchanged")
+	}
+	SlogWith(context.Background(), base).Info("without span")
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("logged %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if lines[0]["trace_id"] != sc.TraceID().String() || lines[0]["span_id"] != sc.SpanID().String() {
+		t.Errorf("trace_id/span_id = %v/%v, want %s/%s", lines[0]["trace_id"], lines[0]["span_id"], sc.TraceID(), sc.SpanID())
+	}
+	for _, key := range []string{"trace_id", "span_id"} {
+		if v, ok := lines[1][key]; ok {
+			t.Errorf("record without a span has %s = %v", key, v)
+		}
+	}
+
+	// logger 为 nil 时使用 slog.Default()
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	buf.Reset()
+	slog.SetDefault(base)
+	SlogWith(trace.ContextWithSpanContext(context.Background(), sc), nil).Info("default")
+	if lines := decodeLogLines(t, &buf); len(lines) != 1 || lines[0]["trace_id"] != sc.TraceID().String() {
+		t.Errorf("nil logger did not fall back to slog.Default: %s", buf.String())
+	}
+}
@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanOptions span 起始选项的链式构造器，可直接展开传给 ContextWithSpan/WithSpan：
+//
+//	ctx, span := telemetry.ContextWithSpan(ctx, "storage.store",
+//		telemetry.SpanOpts().Kind(trace.SpanKindClient).Attr(attribute.String("data.id", id))...,
+//	)
+type SpanOptions []trace.SpanStartOption
+
+// SpanOpts 创建空的 span 选项构造器
+func SpanOpts() SpanOptions {
+	return nil
+}
+
+// Kind 设置 span 类型
+func (o SpanOptions) Kind(kind trace.SpanKind) SpanOptions {
+	return o.with(trace.WithSpanKind(kind))
+}
+
+// Attr 添加单个属性
+func (o SpanOptions) Attr(attr attribute.KeyValue) SpanOptions {
+	return o.with(trace.WithAttributes(attr))
+}
+
+// Attrs 添加多个属性
+func (o SpanOptions) Attrs(attrs ...attribute.KeyValue) SpanOptions {
+	return o.with(trace.WithAttributes(attrs...))
+}
+
+// Links 添加指向其他 span 的链接
+func (o SpanOptions) Links(links ...trace.Link) SpanOptions {
+	return o.with(trace.WithLinks(links...))
+}
+
+// Timestamp 设置 span 的开始时间
+func (o SpanOptions) Timestamp(t time.Time) SpanOptions {
+	return o.with(trace.WithTimestamp(t))
+}
+
+// with 返回追加了 opt 的新切片，不修改 o 的底层数组，因此同一个构造器可以安全地派生出多组选项
+func (o SpanOptions) with(opt trace.SpanStartOption) SpanOptions {
+	out := make(SpanOptions, len(o), len(o)+1)
+	copy(out, o)
+	return append(out, opt)
+}
@@ -0,0 +1,77 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanOptions(t *testing.T) {
+	linked := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{0x11}, SpanID: trace.SpanID{0x12}})
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	opts := SpanOpts().
+		Kind(trace.SpanKindClient).
+		Attr(attribute.String("data.id", "id-1")).
+		Attrs(attribute.Int("data.size", 42), attribute.Bool("cached", true)).
+		Links(trace.Link{SpanContext: linked}).
+		Timestamp(start)
+	if len(opts) != 5 {
+		t.Fatalf("built %d options, want 5", len(opts))
+	}
+
+	cfg := trace.NewSpanStartConfig(opts...)
+	if cfg.SpanKind() != trace.SpanKindClient {
+		t.Errorf("kind = %s, want client", cfg.SpanKind())
+	}
+	attrs := attribute.NewSet(cfg.Attributes()...)
+	if v, _ := attrs.Value("data.id"); v.AsString() != "id-1" {
+		t.Errorf("data.id = %q, want id-1", v.AsString())
+	}
+	if v, _ := attrs.Value("data.size"); v.AsInt64() != 42 {
+		t.Errorf("data.size = %d, want 42", v.AsInt64())
+	}
+	if v, _ := attrs.Value("cached"); !v.AsBool() {
+		t.Error("cached = false, want true")
+	}
+	if links := cfg.Links(); len(links) != 1 || !links[0].SpanContext.Equal(linked) {
+		t.Errorf("links = %v, want one link to %s", links, linked.SpanID())
+	}
+	if !cfg.Timestamp().Equal(start) {
+		t.Errorf("timestamp = %s, want %s", cfg.Timestamp(), start)
+	}
+
+	// 构造出的选项可直接传给 ContextWithSpan
+	exporter := setupTestTracing(t)
+	_, span := ContextWithSpan(context.Background(), "with-opts", opts...)
+	span.End()
+	stub, ok := findSpan(exporter.GetSpans(), "with-opts")
+	if !ok {
+		t.Fatal("span not exported")
+	}
+	if stub.SpanKind != trace.SpanKindClient || !stub.StartTime.Equal(start) || len(stub.Links) != 1 {
+		t.Errorf("span kind = %s, start = %s, links = %d, want client at %s with one link", stub.SpanKind, stub.StartTime, len(stub.Links), start)
+	}
+}
+
+func TestSpanOptionsDoNotShareBackingArray(t *testing.T) {
+	base := SpanOpts().Attr(attribute.String("component", "storage"))
+	// 预留容量后从同一个构造器派生两组选项，彼此不应互相覆盖
+	base = append(make(SpanOptions, 0, 4), base...)
+	read := base.Kind(trace.SpanKindClient)
+	write := base.Kind(trace.SpanKindProducer)
+
+	readCfg, writeCfg := trace.NewSpanStartConfig(read...), trace.NewSpanStartConfig(write...)
+	if got := readCfg.SpanKind(); got != trace.SpanKindClient {
+		t.Errorf("read kind = %s, want client", got)
+	}
+	if got := writeCfg.SpanKind(); got != trace.SpanKindProducer {
+		t.Errorf("write kind = %s, want producer", got)
+	}
+	if len(base) != 1 {
+		t.Errorf("base has %d options after deriving, want 1", len(base))
+	}
+}
@@ -0,0 +1,212 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// spoolFileSuffix 落盘的导出请求文件后缀
+const spoolFileSuffix = ".otlp"
+
+const (
+	// spoolReplayTimeout 单轮后台重放的超时，与触发重放的导出请求的超时无关
+	spoolReplayTimeout = 30 * time.Second
+	// spoolReplayBatch 单轮后台重放最多发送的文件数，剩余文件在下一次导出成功后继续重放
+	spoolReplayBatch = 100
+)
+
+// spoolClient 包装 OTLP trace 客户端：导出失败时将请求写入磁盘，恢复连接后在后台按时间顺序重放
+// 磁盘占用超过 maxBytes 时删除最旧的文件，超过 retention 的文件在重放时直接丢弃
+type spoolClient struct {
+	next      otlptrace.Client
+	dir       string
+	maxBytes  int64
+	retention time.Duration
+
+	// mu 串行化落盘与按大小清理；重放由 replaying 保证同一时间只有一轮，不持有 mu，避免阻塞落盘
+	mu  sync.Mutex
+	seq atomic.Uint64
+
+	// 后台重放：同一时间最多一轮，Stop 时取消并等待其结束
+	replaying    atomic.Bool
+	replayCtx    context.Context
+	cancelReplay context.CancelFunc
+	replayWG     sync.WaitGroup
+}
+
+// newSpoolClient 创建落盘客户端，dir 不存在时自动创建
+func newSpoolClient(next otlptrace.Client, cfg Config) (*spoolClient, error) {
+	if err := os.MkdirAll(cfg.SpoolDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool dir: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &spoolClient{
+		next:         next,
+		dir:          cfg.SpoolDir,
+		maxBytes:     int64(cfg.SpoolMaxBytes),
+		retention:    cfg.SpoolRetention,
+		replayCtx:    ctx,
+		cancelReplay: cancel,
+	}, nil
+}
+
+// Start 启动底层客户端
+func (c *spoolClient) Start(ctx context.Context) error {
+	return c.next.Start(ctx)
+}
+
+// Stop 中止后台重放并停止底层客户端，未重放的文件保留在磁盘上，下次启动后继续重放
+func (c *spoolClient) Stop(ctx context.Context) error {
+	c.cancelReplay()
+	c.replayWG.Wait()
+	return c.next.Stop(ctx)
+}
+
+// UploadTraces 导出 span；失败时落盘并视为成功，成功时在后台重放此前落盘的请求
+func (c *spoolClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	if err := c.next.UploadTraces(ctx, protoSpans); err != nil {
+		if spoolErr := c.spool(protoSpans); spoolErr != nil {
+			zap.L().Warn("Failed to spool spans", zap.Error(spoolErr))
+			return err
+		}
+		zap.L().Warn("OTLP export failed, spans spooled to disk",
+			zap.String("spool_dir", c.dir),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	c.startReplay()
+	return nil
+}
+
+// startReplay 在后台启动一轮重放，已有重放在进行或客户端已停止时不启动
+// 重放使用独立的超时，不占用触发它的导出请求的时间
+func (c *spoolClient) startReplay() {
+	if c.replayCtx.Err() != nil || !c.replaying.CompareAndSwap(false, true) {
+		return
+	}
+	c.replayWG.Add(1)
+	go func() {
+		defer c.replayWG.Done()
+		defer c.replaying.Store(false)
+		ctx, cancel := context.WithTimeout(c.replayCtx, spoolReplayTimeout)
+		defer cancel()
+		c.replay(ctx)
+	}()
+}
+
+// spool 将导出请求写入磁盘，并按 maxBytes 清理最旧的文件
+func (c *spoolClient) spool(protoSpans []*tracepb.ResourceSpans) error {
+	data, err := proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 文件名以纳秒时间戳与序号开头，字典序即写入顺序
+	name := fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), c.seq.Add(1), spoolFileSuffix)
+	tmp := filepath.Join(c.dir, name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(c.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+
+	c.enforceMaxBytes()
+	return nil
+}
+
+// replay 按时间顺序重放最多 spoolReplayBatch 个落盘的请求，遇到失败即停止，等待下一次成功导出后继续
+func (c *spoolClient) replay(ctx context.Context) {
+	files := c.files()
+	if len(files) > spoolReplayBatch {
+		files = files[:spoolReplayBatch]
+	}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		path := filepath.Join(c.dir, file.Name())
+
+		if c.retention > 0 {
+			if info, err := file.Info(); err == nil && time.Since(info.ModTime()) > c.retention {
+				_ = os.Remove(path)
+				continue
+			}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(data, &req); err != nil {
+			zap.L().Warn("Discarding corrupt spool file", zap.String("file", path), zap.Error(err))
+			_ = os.Remove(path)
+			continue
+		}
+		if err := c.next.UploadTraces(ctx, req.ResourceSpans); err != nil {
+			return
+		}
+		_ = os.Remove(path)
+	}
+}
+
+// enforceMaxBytes 删除最旧的文件直到总大小不超过 maxBytes，调用方需持有 mu
+func (c *spoolClient) enforceMaxBytes() {
+	if c.maxBytes <= 0 {
+		return
+	}
+
+	files := c.files()
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, file := range files {
+		if info, err := file.Info(); err == nil {
+			sizes[i] = info.Size()
+			total += sizes[i]
+		}
+	}
+
+	for i := 0; i < len(files) && total > c.maxBytes; i++ {
+		if err := os.Remove(filepath.Join(c.dir, files[i].Name())); err == nil {
+			total -= sizes[i]
+		}
+	}
+}
+
+// files 按写入顺序返回落盘文件
+func (c *spoolClient) files() []os.DirEntry {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil
+	}
+	files := entries[:0]
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), spoolFileSuffix) {
+			files = append(files, entry)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+	return files
+}
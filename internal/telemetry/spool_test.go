@@ -0,0 +1,107 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// countingClient 统计上传次数的 otlptrace.Client，上传时上下文已取消则失败
+type countingClient struct {
+	mu      sync.Mutex
+	uploads int
+}
+
+func (c *countingClient) Start(context.Context) error { return nil }
+
+func (c *countingClient) Stop(context.Context) error { return nil }
+
+func (c *countingClient) UploadTraces(ctx context.Context, _ []*tracepb.ResourceSpans) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uploads++
+	return nil
+}
+
+func (c *countingClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.uploads
+}
+
+// newTestSpoolClient 创建落盘到临时目录的客户端，并预先落盘 n 个请求
+func newTestSpoolClient(t *testing.T, next *countingClient, n int) *spoolClient {
+	t.Helper()
+	cfg := testProviderConfig()
+	cfg.SpoolDir = t.TempDir()
+	c, err := newSpoolClient(next, cfg)
+	if err != nil {
+		t.Fatalf("newSpoolClient: %v", err)
+	}
+	t.Cleanup(func() { _ = c.Stop(context.Background()) })
+	for i := 0; i < n; i++ {
+		if err := c.spool(nil); err != nil {
+			t.Fatalf("spool: %v", err)
+		}
+	}
+	return c
+}
+
+func TestSpoolReplayOutlivesExportContext(t *testing.T) {
+	next := &countingClient{}
+	c := newTestSpoolClient(t, next, 3)
+
+	// 触发重放的导出请求返回后其上下文即被取消，重放不应受影响
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.UploadTraces(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	waitFor(t, 5*time.Second, func() bool { return len(c.files()) == 0 })
+	if got := next.count(); got != 4 {
+		t.Errorf("uploads = %d, want the export plus 3 replayed files", got)
+	}
+}
+
+func TestSpoolReplayBatchCap(t *testing.T) {
+	next := &countingClient{}
+	c := newTestSpoolClient(t, next, spoolReplayBatch+5)
+
+	c.replay(context.Background())
+	if got := next.count(); got != spoolReplayBatch {
+		t.Errorf("replayed %d files in one round, want %d", got, spoolReplayBatch)
+	}
+	if got := len(c.files()); got != 5 {
+		t.Errorf("%d files left after one round, want 5", got)
+	}
+}
+
+func TestSpoolReplaysAfterCollectorOutage(t *testing.T) {
+	collector, addr := startFakeCollector(t, "127.0.0.1:0")
+	cfg := otlpTestConfig(addr)
+	cfg.SpoolDir = t.TempDir()
+	tp := setupTestOTLPTracing(t, cfg)
+	defer tp.Shutdown(context.Background())
+	spool := &spoolClient{dir: cfg.SpoolDir}
+
+	// collector 停止期间导出的 span 落盘
+	collector.server.Stop()
+	exportTestSpan(tp)
+	exportTestSpan(tp)
+	if got := len(spool.files()); got != 2 {
+		t.Fatalf("%d spool files during outage, want 2", got)
+	}
+
+	restarted, _ := startFakeCollector(t, addr)
+	waitFor(t, 10*time.Second, func() bool {
+		exportTestSpan(tp)
+		return len(spool.files()) == 0 && restarted.received() >= 3
+	})
+}
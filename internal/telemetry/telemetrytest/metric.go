@@ -0,0 +1,54 @@
+package telemetrytest
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	apimetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// MetricProvider 基于 ManualReader 的测试用 metric provider，可按需读取聚合结果
+type MetricProvider struct {
+	reader        *metric.ManualReader
+	meterProvider *metric.MeterProvider
+}
+
+// NewMetricProvider 创建测试用 metric provider 并设置为全局 provider，
+// 经 telemetry.Meter 创建的 instrument 的数据可通过 Collect 读取
+// 返回的清理函数会关闭 provider 并恢复之前的全局 provider
+func NewMetricProvider() (*MetricProvider, func()) {
+	reader := metric.NewManualReader()
+	mp := metric.NewMeterProvider(metric.WithReader(reader))
+
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(mp)
+
+	tmp := &MetricProvider{
+		reader:        reader,
+		meterProvider: mp,
+	}
+	cleanup := func() {
+		_ = mp.Shutdown(context.Background())
+		otel.SetMeterProvider(prev)
+	}
+	return tmp, cleanup
+}
+
+// Meter 从测试 provider 获取 meter
+func (p *MetricProvider) Meter(name string) apimetric.Meter {
+	return p.meterProvider.Meter(name)
+}
+
+// MeterProvider 返回底层 MeterProvider
+func (p *MetricProvider) MeterProvider() *metric.MeterProvider {
+	return p.meterProvider
+}
+
+// Collect 读取当前所有指标的聚合结果
+func (p *MetricProvider) Collect() metricdata.ResourceMetrics {
+	var rm metricdata.ResourceMetrics
+	_ = p.reader.Collect(context.Background(), &rm)
+	return rm
+}
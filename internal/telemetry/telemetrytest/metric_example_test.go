@@ -0,0 +1,30 @@
+package telemetrytest_test
+
+import (
+	"context"
+	"fmt"
+
+	"optl/internal/telemetry"
+	"optl/internal/telemetry/telemetrytest"
+
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func ExampleMetricProvider() {
+	mp, cleanup := telemetrytest.NewMetricProvider()
+	defer cleanup()
+
+	// 被测代码通过全局 provider 记录指标
+	counter, _ := telemetry.Meter("orders").Int64Counter("orders_created")
+	counter.Add(context.Background(), 2)
+	counter.Add(context.Background(), 1)
+
+	for _, sm := range mp.Collect().ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == "orders_created" {
+				fmt.Println(m.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+			}
+		}
+	}
+	// Output: 3
+}
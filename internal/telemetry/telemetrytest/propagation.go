@@ -0,0 +1,119 @@
+// Package telemetrytest 提供用于回归测试遥测中间件的辅助函数
+package telemetrytest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"optl/internal/telemetry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// KnownSpanContext 返回固定 trace ID 与 span ID 的已采样 span 上下文，作为传播测试的输入
+func KnownSpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
+// requirePropagator 确认已配置全局传播器，否则传播测试没有意义
+func requirePropagator(t testing.TB) {
+	t.Helper()
+	if len(otel.GetTextMapPropagator().Fields()) == 0 {
+		t.Fatal("no global text map propagator configured; call telemetry.SetupTracing or otel.SetTextMapPropagator first")
+	}
+}
+
+// RoundTripPropagationHTTP 启动使用 mw 的内存 HTTP 服务端，客户端通过 PropagateContext 注入已知的追踪上下文，
+// 断言服务端经 ExtractContext 观察到相同的 trace ID
+func RoundTripPropagationHTTP(t testing.TB, mw *telemetry.HTTPMiddleware) {
+	t.Helper()
+	requirePropagator(t)
+
+	observed := make(chan trace.SpanContext, 1)
+	server := httptest.NewServer(mw.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		observed <- trace.SpanContextFromContext(mw.ExtractContext(r))
+	})))
+	defer server.Close()
+
+	want := KnownSpanContext()
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), want)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := server.Client().Do(mw.PropagateContext(ctx, req))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	assertTraceID(t, want, <-observed)
+}
+
+// RoundTripPropagationGRPC 启动使用 mw 的内存 gRPC 服务端（bufconn），客户端通过 PropagateContext 注入已知的追踪上下文，
+// 断言服务端经 ExtractContext 观察到相同的 trace ID
+func RoundTripPropagationGRPC(t testing.TB, mw *telemetry.GRPCMiddleware) {
+	t.Helper()
+	requirePropagator(t)
+
+	observed := make(chan trace.SpanContext, 1)
+	observe := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		observed <- trace.SpanContextFromContext(mw.ExtractContext(ctx))
+		return handler(ctx, req)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(append(mw.ServerOptions(), grpc.ChainUnaryInterceptor(observe))...)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		mw.DialOption(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	want := KnownSpanContext()
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), want)
+
+	if _, err := healthpb.NewHealthClient(conn).Check(mw.PropagateContext(ctx), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+
+	assertTraceID(t, want, <-observed)
+}
+
+// assertTraceID 断言服务端观察到的 trace ID 与注入的一致
+func assertTraceID(t testing.TB, want, got trace.SpanContext) {
+	t.Helper()
+	if !got.IsValid() {
+		t.Fatalf("server observed no valid span context, want trace ID %s", want.TraceID())
+	}
+	if got.TraceID() != want.TraceID() {
+		t.Fatalf("server observed trace ID %s, want %s", got.TraceID(), want.TraceID())
+	}
+}
@@ -0,0 +1,35 @@
+package telemetrytest_test
+
+import (
+	"testing"
+
+	"optl/internal/telemetry"
+	"optl/internal/telemetry/telemetrytest"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// setupPropagation 配置全局 W3C 传播器与 tracer provider，测试结束时恢复
+func setupPropagation(t *testing.T) {
+	t.Helper()
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	tp := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+}
+
+func TestRoundTripPropagationHTTP(t *testing.T) {
+	setupPropagation(t)
+	telemetrytest.RoundTripPropagationHTTP(t, telemetry.NewHTTPMiddleware("propagation-test"))
+}
+
+func TestRoundTripPropagationGRPC(t *testing.T) {
+	setupPropagation(t)
+	telemetrytest.RoundTripPropagationGRPC(t, telemetry.NewGRPCMiddleware("propagation-test"))
+}
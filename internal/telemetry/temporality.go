@@ -0,0 +1,47 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// instrumentKinds TemporalityByKind 支持的 instrument 类型键
+var instrumentKinds = map[string]metric.InstrumentKind{
+	"counter":                 metric.InstrumentKindCounter,
+	"updowncounter":           metric.InstrumentKindUpDownCounter,
+	"histogram":               metric.InstrumentKindHistogram,
+	"gauge":                   metric.InstrumentKindGauge,
+	"observablecounter":       metric.InstrumentKindObservableCounter,
+	"observableupdowncounter": metric.InstrumentKindObservableUpDownCounter,
+	"observablegauge":         metric.InstrumentKindObservableGauge,
+}
+
+// newTemporalitySelector 根据按 instrument 类型配置的聚合时间性构造选择器，未配置的类型使用 cumulative
+// 类型键或时间性取值无法识别时返回错误
+func newTemporalitySelector(byKind map[string]string) (metric.TemporalitySelector, error) {
+	temporalities := make(map[metric.InstrumentKind]metricdata.Temporality, len(byKind))
+	for key, value := range byKind {
+		kind, ok := instrumentKinds[strings.ToLower(key)]
+		if !ok {
+			return nil, fmt.Errorf("unknown instrument kind %q in temporality config", key)
+		}
+		switch strings.ToLower(value) {
+		case "delta":
+			temporalities[kind] = metricdata.DeltaTemporality
+		case "cumulative":
+			temporalities[kind] = metricdata.CumulativeTemporality
+		default:
+			return nil, fmt.Errorf("unknown temporality %q for instrument kind %q", value, key)
+		}
+	}
+
+	return func(kind metric.InstrumentKind) metricdata.Temporality {
+		if temporality, ok := temporalities[kind]; ok {
+			return temporality
+		}
+		return metricdata.CumulativeTemporality
+	}, nil
+}
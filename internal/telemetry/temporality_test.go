@@ -0,0 +1,42 @@
+package telemetry
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestNewTemporalitySelector(t *testing.T) {
+	selector, err := newTemporalitySelector(map[string]string{
+		"Counter":   "delta",
+		"histogram": "DELTA",
+		"gauge":     "cumulative",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[metric.InstrumentKind]metricdata.Temporality{
+		metric.InstrumentKindCounter:   metricdata.DeltaTemporality,
+		metric.InstrumentKindHistogram: metricdata.DeltaTemporality,
+		metric.InstrumentKindGauge:     metricdata.CumulativeTemporality,
+		// 未配置的类型使用 cumulative
+		metric.InstrumentKindUpDownCounter:     metricdata.CumulativeTemporality,
+		metric.InstrumentKindObservableCounter: metricdata.CumulativeTemporality,
+	}
+	for kind, temporality := range want {
+		if got := selector(kind); got != temporality {
+			t.Errorf("selector(%s) = %s, want %s", kind, got, temporality)
+		}
+	}
+
+	for _, byKind := range []map[string]string{
+		{"timer": "delta"},
+		{"counter": "sometimes"},
+	} {
+		if _, err := newTemporalitySelector(byKind); err == nil {
+			t.Errorf("newTemporalitySelector(%v) returned no error", byKind)
+		}
+	}
+}
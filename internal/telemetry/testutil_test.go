@@ -0,0 +1,120 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// setupTestTracing 将全局 tracer provider 替换为同步导出到内存的 provider，测试结束时恢复
+func setupTestTracing(t *testing.T, opts ...sdktrace.TracerProviderOption) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(append(opts, sdktrace.WithSyncer(exporter))...)
+
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// setupTestMetrics 将全局 meter provider 替换为挂载手动 reader 的 provider，测试结束时恢复
+func setupTestMetrics(t *testing.T) *sdkmetric.ManualReader {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(mp)
+	t.Cleanup(func() {
+		otel.SetMeterProvider(prev)
+		_ = mp.Shutdown(context.Background())
+	})
+	return reader
+}
+
+// collectMetric 收集一次指标并返回指定名称的指标及其 scope
+func collectMetric(t *testing.T, reader sdkmetric.Reader, name string) (metricdata.ScopeMetrics, metricdata.Metrics, bool) {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collect metrics: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return sm, m, true
+			}
+		}
+	}
+	return metricdata.ScopeMetrics{}, metricdata.Metrics{}, false
+}
+
+// findSpan 返回指定名称的 span
+func findSpan(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, s := range spans {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+// testProviderConfig 返回不连接外部服务的配置：指标经 Prometheus 注册表读取，span 保留在调试缓冲区中
+func testProviderConfig() Config {
+	cfg := DefaultConfig()
+	cfg.ServiceName = "provider-test"
+	cfg.OTLPEndpoint = ""
+	cfg.EnableConsoleExporter = false
+	cfg.EnablePrometheusExporter = true
+	cfg.EnableRuntimeMetrics = false
+	cfg.DebugSpanBufferSize = 100
+	cfg.LogLevel = "error"
+	return cfg
+}
+
+// newTestProvider 创建 Provider，测试结束时关闭并恢复全局 provider
+func newTestProvider(t *testing.T, cfg Config) *Provider {
+	t.Helper()
+	prevTP, prevMP := otel.GetTracerProvider(), otel.GetMeterProvider()
+	p, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = p.Shutdown(context.Background())
+		otel.SetTracerProvider(prevTP)
+		otel.SetMeterProvider(prevMP)
+	})
+	return p
+}
+
+// scrape 读取 Prometheus 端点的输出
+func scrape(p *Provider) string {
+	rec := httptest.NewRecorder()
+	p.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rec.Body.String()
+}
+
+// waitFor 在超时前反复检查条件，超时仍不满足时测试失败
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", timeout)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
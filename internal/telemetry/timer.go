@@ -0,0 +1,38 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartTimer 开始计时，返回的停止函数会将经过的毫秒数记录到直方图（通常配合 defer 使用）
+func StartTimer(ctx context.Context, hist metric.Float64Histogram, attrs ...attribute.KeyValue) func() {
+	start := time.Now()
+	return func() {
+		elapsedMs := float64(time.Since(start)) / float64(time.Millisecond)
+		hist.Record(ctx, elapsedMs, metric.WithAttributes(attrs...))
+	}
+}
+
+// RecordWithExemplar 在当前 span 的上下文中记录直方图值，使 SDK 将该 span 的 trace/span ID 作为 exemplar 附加到这次记录上
+// exemplar 是否被采集由 SDK 的 exemplar filter 决定（OTEL_METRICS_EXEMPLAR_FILTER）：默认的 trace_based 只为已采样的 span
+// 采集，always_on 对所有带 span 的记录采集，always_off 则完全不采集；ctx 中没有有效 span 时等同于普通记录
+func RecordWithExemplar(ctx context.Context, hist metric.Float64Histogram, value float64, attrs ...attribute.KeyValue) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		LoggerWithContext(ctx).Debug("RecordWithExemplar called without an active span, recording without exemplar")
+	}
+	hist.Record(ctx, value, metric.WithAttributes(attrs...))
+}
+
+// Observe 在名为 name 的 span 中执行 fn，将耗时（毫秒）以 attrs 记录到 hist，fn 出错时记录错误并将 span 状态设为 Error
+// 耗时无论成功失败都会记录，且在 span 上下文中记录以便附加 exemplar；attrs 同时作为 span 的起始属性
+func Observe(ctx context.Context, name string, hist metric.Float64Histogram, fn func(context.Context) error, attrs ...attribute.KeyValue) error {
+	return WithSpan(ctx, name, func(ctx context.Context) error {
+		defer StartTimer(ctx, hist, attrs...)()
+		return fn(ctx)
+	}, trace.WithAttributes(attrs...))
+}
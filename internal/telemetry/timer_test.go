@@ -0,0 +1,148 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newExemplarTestHistogram 创建使用指定 exemplar filter 的直方图及其手动 reader
+func newExemplarTestHistogram(t *testing.T, filter exemplar.Filter) (*sdkmetric.ManualReader, metric.Float64Histogram) {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithExemplarFilter(filter))
+	t.Cleanup(func() { _ = mp.Shutdown(context.Background()) })
+	hist, err := mp.Meter("timer-test").Float64Histogram("latency")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reader, hist
+}
+
+// histogramPoint 收集一次并返回直方图唯一的数据点
+func histogramPoint(t *testing.T, reader sdkmetric.Reader, name string) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+	_, m, ok := collectMetric(t, reader, name)
+	if !ok {
+		t.Fatalf("%s not collected", name)
+	}
+	points := m.Data.(metricdata.Histogram[float64]).DataPoints
+	if len(points) != 1 {
+		t.Fatalf("%s has %d data points, want 1", name, len(points))
+	}
+	return points[0]
+}
+
+func TestRecordWithExemplar(t *testing.T) {
+	sampled := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x0c},
+		SpanID:     trace.SpanID{0x0d},
+		TraceFlags: trace.FlagsSampled,
+	})
+	unsampled := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x0e},
+		SpanID:  trace.SpanID{0x0f},
+	})
+
+	tests := []struct {
+		name   string
+		filter exemplar.Filter
+		sc     trace.SpanContext
+		want   bool
+	}{
+		{name: "trace based, sampled span", filter: exemplar.TraceBasedFilter, sc: sampled, want: true},
+		{name: "trace based, unsampled span", filter: exemplar.TraceBasedFilter, sc: unsampled, want: false},
+		{name: "always on, unsampled span", filter: exemplar.AlwaysOnFilter, sc: unsampled, want: true},
+		{name: "always off, sampled span", filter: exemplar.AlwaysOffFilter, sc: sampled, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, hist := newExemplarTestHistogram(t, tt.filter)
+			ctx := trace.ContextWithSpanContext(context.Background(), tt.sc)
+			RecordWithExemplar(ctx, hist, 12.5, attribute.String("route", "/orders"))
+
+			exemplars := histogramPoint(t, reader, "latency").Exemplars
+			if !tt.want {
+				if len(exemplars) != 0 {
+					t.Errorf("got %d exemplars, want none", len(exemplars))
+				}
+				return
+			}
+			if len(exemplars) != 1 {
+				t.Fatalf("got %d exemplars, want 1", len(exemplars))
+			}
+			ex := exemplars[0]
+			if trace.TraceID(ex.TraceID) != tt.sc.TraceID() || trace.SpanID(ex.SpanID) != tt.sc.SpanID() {
+				t.Errorf("exemplar trace/span = %x/%x, want %s/%s", ex.TraceID, ex.SpanID, tt.sc.TraceID(), tt.sc.SpanID())
+			}
+			if ex.Value != 12.5 {
+				t.Errorf("exemplar value = %v, want 12.5", ex.Value)
+			}
+		})
+	}
+}
+
+func TestObserve(t *testing.T) {
+	exporter := setupTestTracing(t)
+	reader, hist := newExemplarTestHistogram(t, exemplar.TraceBasedFilter)
+	routeAttr := attribute.String("route", "/orders")
+	errBoom := errors.New("boom")
+
+	err := Observe(context.Background(), "observed", hist, func(ctx context.Context) error {
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}, routeAttr)
+	if err != nil {
+		t.Fatalf("Observe: %v", err)
+	}
+	if err := Observe(context.Background(), "observed-fail", hist, func(context.Context) error {
+		return errBoom
+	}, routeAttr); !errors.Is(err, errBoom) {
+		t.Errorf("Observe error = %v, want boom", err)
+	}
+
+	// span：attrs 作为起始属性，失败时状态为 Error 并记录异常事件
+	spans := exporter.GetSpans()
+	succeeded, found := findSpan(spans, "observed")
+	if !found {
+		t.Fatal("observed span not exported")
+	}
+	if v, _ := attrValue(succeeded.Attributes, "route"); v != "/orders" || succeeded.Status.Code != codes.Unset {
+		t.Errorf("observed span route = %q, status = %v, want /orders and Unset", v, succeeded.Status.Code)
+	}
+	failed, found := findSpan(spans, "observed-fail")
+	if !found {
+		t.Fatal("observed-fail span not exported")
+	}
+	if failed.Status.Code != codes.Error || len(failed.Events) != 1 || failed.Events[0].Name != "exception" {
+		t.Errorf("observed-fail status = %v, events = %v, want Error with an exception event", failed.Status.Code, failed.Events)
+	}
+
+	// 指标：成功与失败各记录一次耗时，带 attrs，并以所在 span 作为 exemplar
+	point := histogramPoint(t, reader, "latency")
+	if point.Count != 2 {
+		t.Errorf("histogram count = %d, want 2", point.Count)
+	}
+	if v, _ := point.Attributes.Value("route"); v.AsString() != "/orders" {
+		t.Errorf("histogram route = %q, want /orders", v.AsString())
+	}
+	if maxMs, _ := point.Max.Value(); maxMs < 10 {
+		t.Errorf("max recorded duration = %vms, want at least 10ms", maxMs)
+	}
+	var exemplarSpans []trace.SpanID
+	for _, ex := range point.Exemplars {
+		exemplarSpans = append(exemplarSpans, trace.SpanID(ex.SpanID))
+	}
+	if len(exemplarSpans) == 0 || (exemplarSpans[0] != succeeded.SpanContext.SpanID() && exemplarSpans[0] != failed.SpanContext.SpanID()) {
+		t.Errorf("exemplar spans = %v, want one of the observed spans", exemplarSpans)
+	}
+}
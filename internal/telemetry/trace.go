@@ -4,33 +4,48 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
 	"go.opentelemetry.io/otel/trace"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
+// consoleWriter 控制台导出器的输出目标，声明为变量以便测试捕获输出
+var consoleWriter io.Writer = os.Stdout
+
 // TraceProvider 封装 trace provider 和 cleanup 函数
 type TraceProvider struct {
-	provider *sdktrace.TracerProvider
-	cleanup  func() error
+	provider        *sdktrace.TracerProvider
+	sampler         *sampler
+	recentSpans     *recentSpanProcessor
+	dependencyGraph *dependencyGraphProcessor
+	console         *toggleSpanExporter
+	cleanup         func() error
 }
 
-// SetupTracing 配置追踪功能
+// SetupTracing 配置追踪功能，采样决定等自观测指标记录到全局 meter provider
 func SetupTracing(cfg Config) (*TraceProvider, error) {
+	return setupTracing(cfg, otel.GetMeterProvider())
+}
+
+// setupTracing 配置追踪功能，自观测指标绑定到 mp；Provider 传入与之一同构建的 meter provider，
+// 因此需先于 trace 完成指标初始化
+func setupTracing(cfg Config, mp metric.MeterProvider) (*TraceProvider, error) {
+	meter := scopedMeter(mp, internalScopeName)
+
 	// 创建资源属性
 	res, err := createResource(cfg)
 	if err != nil {
@@ -40,25 +55,31 @@ func SetupTracing(cfg Config) (*TraceProvider, error) {
 	// 配置导出器
 	var (
 		exporter sdktrace.SpanExporter
+		console  *toggleSpanExporter
 		cleanup  func() error
 	)
 
 	if cfg.EnableConsoleExporter {
-		consoleExporter, err := stdouttrace.New(
-			stdouttrace.WithPrettyPrint(),
-		)
+		consoleOpts := []stdouttrace.Option{stdouttrace.WithWriter(consoleWriter)}
+		if !cfg.ConsoleExporterCompact {
+			consoleOpts = append(consoleOpts, stdouttrace.WithPrettyPrint())
+		}
+		consoleExporter, err := stdouttrace.New(consoleOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
 		}
 
+		// 控制台输出可在运行时开关（见 Provider.SetConsoleExporter）
+		console = newToggleSpanExporter(consoleExporter)
+
 		if exporter == nil {
-			exporter = consoleExporter
+			exporter = console
 			cleanup = func() error {
 				return consoleExporter.Shutdown(context.Background())
 			}
 		} else {
 			// 多导出器组合
-			multiExporter := newMultiSpanExporter(exporter, consoleExporter)
+			multiExporter := newMultiSpanExporter(exporter, console)
 			//bsp := sdktrace.NewBatchSpanProcessor(multiExporter)
 			exporter = multiExporter
 			oldCleanup := cleanup
@@ -73,68 +94,88 @@ func SetupTracing(cfg Config) (*TraceProvider, error) {
 		}
 	}
 
-	// 添加 OTLP 导出器
+	// 添加 OTLP 导出器（gRPC 或 HTTP，见 Config.OTLPProtocol）
 	if cfg.OTLPEndpoint != "" {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+		endpoint, err := parseOTLPEndpoint(cfg.OTLPEndpoint, cfg.OTLPProtocol)
+		if err != nil {
+			return nil, err
+		}
 
-		// 配置 gRPC 连接选项
-		var grpcOpts []grpc.DialOption
-		
-		// 配置 TLS 凭据
-		if cfg.TLSConfig.Enabled {
-			tlsConfig, err := createTLSConfig(cfg.TLSConfig)
+		var (
+			client otlptrace.Client
+			// 关闭 gRPC 连接，HTTP 协议下为空操作
+			closeConn = func() error { return nil }
+		)
+		if endpoint.protocol == otlpProtocolHTTP {
+			client, err = newOTLPTraceHTTPClient(cfg, endpoint)
 			if err != nil {
-				return nil, fmt.Errorf("failed to create TLS config: %w", err)
+				return nil, err
 			}
-			grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
 		} else {
-			grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		}
+			conn, err := dialOTLP(cfg, "traces")
+			if err != nil {
+				return nil, err
+			}
+
+			// 配置 OTLP 客户端选项
+			var clientOpts []otlptracegrpc.Option
+			clientOpts = append(clientOpts, otlptracegrpc.WithGRPCConn(conn))
+
+			// 配置单次导出超时
+			if cfg.OTLPExportTimeout > 0 {
+				clientOpts = append(clientOpts, otlptracegrpc.WithTimeout(cfg.OTLPExportTimeout))
+			}
 		
-		grpcOpts = append(grpcOpts, grpc.WithBlock())
+			// 配置重试选项
+			if cfg.RetryConfig.Enabled {
+				clientOpts = append(clientOpts, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+					Enabled:         true,
+					InitialInterval: cfg.RetryConfig.InitialInterval,
+					MaxInterval:     cfg.RetryConfig.MaxInterval,
+					MaxElapsedTime:  cfg.RetryConfig.MaxElapsedTime,
+					Multiplier:      cfg.RetryConfig.Multiplier,
+					RandomizationFactor: cfg.RetryConfig.RandomizationFactor,
+				}))
+			}
 
-		conn, err := grpc.DialContext(ctx, cfg.OTLPEndpoint, grpcOpts...)
-		if err != nil {
-			return nil, fmt.Errorf("failed to connect to OTLP endpoint: %w", err)
+			client = otlptracegrpc.NewClient(clientOpts...)
+			closeConn = conn.Close
 		}
 
-		// 配置 OTLP 客户端选项
-		var clientOpts []otlptracegrpc.Option
-		clientOpts = append(clientOpts, otlptracegrpc.WithGRPCConn(conn))
-		
-		// 配置重试选项
-		if cfg.RetryConfig.Enabled {
-			clientOpts = append(clientOpts, otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
-				Enabled:         true,
-				InitialInterval: cfg.RetryConfig.InitialInterval,
-				MaxInterval:     cfg.RetryConfig.MaxInterval,
-				MaxElapsedTime:  cfg.RetryConfig.MaxElapsedTime,
-				Multiplier:      cfg.RetryConfig.Multiplier,
-				RandomizationFactor: cfg.RetryConfig.RandomizationFactor,
-			}))
+		// 配置导出失败时落盘，恢复后重放
+		if cfg.SpoolDir != "" {
+			client, err = newSpoolClient(client, cfg)
+			if err != nil {
+				closeConn()
+				return nil, err
+			}
 		}
 
-		otlpExporter, err := otlptrace.New(
-			context.Background(),
-			otlptracegrpc.NewClient(clientOpts...),
-		)
+		otlpExporter, err := otlptrace.New(context.Background(), client)
 		if err != nil {
+			closeConn()
 			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
 		}
 
+		// 导出器不会关闭通过 WithGRPCConn 传入的连接，需在关闭导出器后一并关闭，连接监视 goroutine 随之退出
+		shutdownOTLP := func() error {
+			err := otlpExporter.Shutdown(context.Background())
+			if closeErr := closeConn(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+			return err
+		}
+
 		if exporter == nil {
 			exporter = otlpExporter
-			cleanup = func() error {
-				return otlpExporter.Shutdown(context.Background())
-			}
+			cleanup = shutdownOTLP
 		} else {
 			// 多导出器组合
 			multiExporter := newMultiSpanExporter(exporter, otlpExporter)
 			oldCleanup := cleanup
 			cleanup = func() error {
 				err1 := oldCleanup()
-				err2 := otlpExporter.Shutdown(context.Background())
+				err2 := shutdownOTLP()
 				if err1 != nil {
 					return err1
 				}
@@ -144,29 +185,91 @@ func SetupTracing(cfg Config) (*TraceProvider, error) {
 		}
 	}
 
-	// 配置采样器
-	var sampler sdktrace.Sampler
-	if cfg.SamplingRatio >= 1.0 {
-		sampler = sdktrace.AlwaysSample()
-	} else if cfg.SamplingRatio <= 0.0 {
-		sampler = sdktrace.NeverSample()
-	} else {
-		sampler = sdktrace.TraceIDRatioBased(cfg.SamplingRatio)
+	// 添加 Perfetto 导出器，关闭时将 span 写入文件
+	if cfg.PerfettoExportPath != "" {
+		perfettoExporter := newPerfettoExporter(cfg.PerfettoExportPath)
+
+		if exporter == nil {
+			exporter = perfettoExporter
+			cleanup = func() error {
+				return perfettoExporter.Shutdown(context.Background())
+			}
+		} else {
+			exporter = newMultiSpanExporter(exporter, perfettoExporter)
+			oldCleanup := cleanup
+			cleanup = func() error {
+				err1 := oldCleanup()
+				err2 := perfettoExporter.Shutdown(context.Background())
+				if err1 != nil {
+					return err1
+				}
+				return err2
+			}
+		}
 	}
 
+	// 配置采样器
+	sampler := newSampler(cfg, meter)
+
 	// 配置处理器
-	bsp := sdktrace.NewBatchSpanProcessor(
-		exporter,
-		sdktrace.WithBatchTimeout(cfg.BatchTimeout),
-		sdktrace.WithMaxExportBatchSize(cfg.MaxExportBatchSize),
-	)
+	bspOpts := batchSpanProcessorOptions(cfg)
+	var bsp sdktrace.SpanProcessor
+	// 跟踪最早未导出 span 的等待时长，用于发现导出停滞
+	if cfg.TrackUnexportedSpanAge && exporter != nil {
+		tracker, err := newExportAgeTracker(meter)
+		if err != nil {
+			closeExporters(cleanup)
+			return nil, err
+		}
+		bsp = &exportAgeSpanProcessor{
+			tracker: tracker,
+			next:    sdktrace.NewBatchSpanProcessor(&exportAgeExporter{SpanExporter: exporter, tracker: tracker}, bspOpts...),
+		}
+	} else {
+		bsp = sdktrace.NewBatchSpanProcessor(exporter, bspOpts...)
+	}
+	// 导出前为应用属性添加命名空间
+	if cfg.AttributeNamespace != "" {
+		bsp = NewNamespaceSpanProcessor(cfg.AttributeNamespace, bsp)
+	}
+	// 导出前丢弃零耗时（或过短）的 span
+	if cfg.DropZeroDurationSpans {
+		bsp = NewDurationFilterSpanProcessor(cfg.MinSpanDuration, bsp)
+	}
+	// 按路由延迟采样的请求在结束时决定是否导出（见 WithRouteSampling）
+	bsp = newRouteSamplingProcessor(bsp)
 
 	// 创建 provider
-	tp := sdktrace.NewTracerProvider(
+	tpOpts := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(res),
 		sdktrace.WithSampler(sampler),
-		sdktrace.WithSpanProcessor(bsp),
-	)
+	}
+	if cfg.IDGenerator != nil {
+		tpOpts = append(tpOpts, sdktrace.WithIDGenerator(cfg.IDGenerator))
+	}
+	// 附加的 span 处理器（属性增强等）先于批处理器注册
+	processors, err := spanProcessors(cfg, meter)
+	if err != nil {
+		closeExporters(cleanup)
+		return nil, err
+	}
+	for _, sp := range processors {
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(sp))
+	}
+	tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(bsp))
+	// 在内存中保留最近的 span 供调试端点查看
+	var recentSpans *recentSpanProcessor
+	if cfg.DebugSpanBufferSize > 0 {
+		recentSpans = newRecentSpanProcessor(cfg.DebugSpanBufferSize)
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(recentSpans))
+	}
+	// 从客户端 span 记录服务依赖图
+	var dependencyGraph *dependencyGraphProcessor
+	if cfg.EnableDependencyGraph {
+		dependencyGraph = newDependencyGraphProcessor()
+		tpOpts = append(tpOpts, sdktrace.WithSpanProcessor(dependencyGraph))
+	}
+	tp := sdktrace.NewTracerProvider(tpOpts...)
 
 	// 设置全局 provider
 	otel.SetTracerProvider(tp)
@@ -178,11 +281,28 @@ func SetupTracing(cfg Config) (*TraceProvider, error) {
 	))
 
 	return &TraceProvider{
-		provider: tp,
-		cleanup:  cleanup,
+		provider:        tp,
+		sampler:         sampler,
+		recentSpans:     recentSpans,
+		dependencyGraph: dependencyGraph,
+		console:         console,
+		cleanup:         cleanup,
 	}, nil
 }
 
+// batchSpanProcessorOptions 根据配置构造批处理器选项
+func batchSpanProcessorOptions(cfg Config) []sdktrace.BatchSpanProcessorOption {
+	opts := []sdktrace.BatchSpanProcessorOption{
+		sdktrace.WithBatchTimeout(cfg.BatchTimeout),
+		sdktrace.WithMaxExportBatchSize(cfg.MaxExportBatchSize),
+	}
+	// 队列满时阻塞调用方而非丢弃 span
+	if cfg.BlockOnQueueFull {
+		opts = append(opts, sdktrace.WithBlocking())
+	}
+	return opts
+}
+
 // Shutdown 关闭 trace provider
 func (tp *TraceProvider) Shutdown(ctx context.Context) error {
 	err := tp.provider.Shutdown(ctx)
@@ -195,9 +315,16 @@ func (tp *TraceProvider) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// Tracer 通过全局 provider 获取 tracer
+// Tracer 通过全局 provider 获取 tracer，使用默认的 instrumentation scope 版本（见 SetDefaultInstrumentationVersion）
 func Tracer(name string) trace.Tracer {
-	return otel.Tracer(name)
+	return TracerVersioned(name, defaultScopeVersion.Load().(string))
+}
+
+// closeExporters 在初始化失败时关闭已创建的导出器及其连接
+func closeExporters(cleanup func() error) {
+	if cleanup != nil {
+		_ = cleanup()
+	}
 }
 
 // multiSpanExporter 实现多导出器组合
@@ -207,13 +334,15 @@ func newMultiSpanExporter(exporters ...sdktrace.SpanExporter) sdktrace.SpanExpor
 	return multiSpanExporter(exporters)
 }
 
+// ExportSpans 将 span 交给每个导出器，某个导出器失败（如 OTLP 不可达）不影响其余导出器，返回所有错误的合并
 func (e multiSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	var errs []error
 	for _, exporter := range e {
 		if err := exporter.ExportSpans(ctx, spans); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (e multiSpanExporter) Shutdown(ctx context.Context) error {
@@ -223,10 +352,26 @@ func (e multiSpanExporter) Shutdown(ctx context.Context) error {
 			errs = append(errs, err)
 		}
 	}
-	if len(errs) > 0 {
-		return fmt.Errorf("multiple errors during shutdown: %v", errs)
+	return errors.Join(errs...)
+}
+
+// toggleSpanExporter 可在运行时开关的导出器，关闭时丢弃 span 并视为导出成功
+type toggleSpanExporter struct {
+	sdktrace.SpanExporter
+	enabled atomic.Bool
+}
+
+func newToggleSpanExporter(next sdktrace.SpanExporter) *toggleSpanExporter {
+	e := &toggleSpanExporter{SpanExporter: next}
+	e.enabled.Store(true)
+	return e
+}
+
+func (e *toggleSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if !e.enabled.Load() {
+		return nil
 	}
-	return nil
+	return e.SpanExporter.ExportSpans(ctx, spans)
 }
 
 // createTLSConfig 创建 TLS 配置
@@ -277,8 +422,15 @@ func createResource(cfg Config) (*resource.Resource, error) {
 		semconv.DeploymentEnvironmentKey.String(cfg.Environment),
 	}
 
-	// 添加服务实例 ID（如果未提供则生成）
-	if instanceID, exists := cfg.ResourceAttributes["service.instance.id"]; exists {
+	// 添加服务命名空间
+	if cfg.ServiceNamespace != "" {
+		attrs = append(attrs, semconv.ServiceNamespaceKey.String(cfg.ServiceNamespace))
+	}
+
+	// 添加服务实例 ID（优先使用显式配置，其次是资源属性，否则生成）
+	if cfg.ServiceInstanceID != "" {
+		attrs = append(attrs, semconv.ServiceInstanceIDKey.String(cfg.ServiceInstanceID))
+	} else if instanceID, exists := cfg.ResourceAttributes["service.instance.id"]; exists {
 		attrs = append(attrs, semconv.ServiceInstanceIDKey.String(instanceID))
 	} else {
 		// 生成默认实例 ID
@@ -286,13 +438,21 @@ func createResource(cfg Config) (*resource.Resource, error) {
 		attrs = append(attrs, semconv.ServiceInstanceIDKey.String(fmt.Sprintf("%s-%d", hostname, os.Getpid())))
 	}
 
+	// 添加从云元数据服务检测到的属性，显式配置的资源属性优先
+	attrs = append(attrs, cloudAttributes(cfg.CloudDetector)...)
+
 	// 添加额外的资源属性
 	for k, v := range cfg.ResourceAttributes {
+		// 实例 ID 已在上面处理，避免覆盖显式配置
+		if k == string(semconv.ServiceInstanceIDKey) {
+			continue
+		}
 		attrs = append(attrs, attribute.String(k, v))
 	}
 
+	// resource.Default() 带有 SDK 内置 semconv 版本的 schema URL，直接合并会与本包使用的版本冲突，因此只取其属性
 	r, err := resource.Merge(
-		resource.Default(),
+		resource.NewSchemaless(resource.Default().Attributes()...),
 		resource.NewWithAttributes(semconv.SchemaURL, attrs...),
 	)
 	if err != nil {
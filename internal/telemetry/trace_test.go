@@ -0,0 +1,171 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+)
+
+func TestCreateResource(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.ServiceNamespace = "shop"
+	cfg.ServiceInstanceID = "instance-1"
+	cfg.ResourceAttributes = map[string]string{"deployment.color": "blue"}
+
+	res, err := createResource(cfg)
+	if err != nil {
+		t.Fatalf("createResource: %v", err)
+	}
+	if res.SchemaURL() != semconv.SchemaURL {
+		t.Errorf("schema URL = %q, want %q", res.SchemaURL(), semconv.SchemaURL)
+	}
+	want := map[string]string{
+		"service.name":        "provider-test",
+		"service.namespace":   "shop",
+		"service.instance.id": "instance-1",
+		"deployment.color":    "blue",
+		"telemetry.sdk.name":  "opentelemetry",
+	}
+	got := make(map[string]string)
+	for _, kv := range res.Attributes() {
+		got[string(kv.Key)] = kv.Value.Emit()
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+// consoleOutput 以控制台导出器导出一个 span 并返回输出
+func consoleOutput(t *testing.T, compact bool) string {
+	t.Helper()
+	var buf bytes.Buffer
+	prevWriter, prevTP := consoleWriter, otel.GetTracerProvider()
+	consoleWriter = &buf
+	t.Cleanup(func() {
+		consoleWriter = prevWriter
+		otel.SetTracerProvider(prevTP)
+	})
+
+	cfg := testProviderConfig()
+	cfg.EnableConsoleExporter = true
+	cfg.ConsoleExporterCompact = compact
+	tp, err := setupTracing(cfg, metricnoop.NewMeterProvider())
+	if err != nil {
+		t.Fatalf("setupTracing: %v", err)
+	}
+	_, span := tp.provider.Tracer("test").Start(context.Background(), "op")
+	span.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	return buf.String()
+}
+
+func TestConsoleExporterCompact(t *testing.T) {
+	out := consoleOutput(t, true)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("compact output has %d lines, want 1:\n%s", len(lines), out)
+	}
+	var span struct{ Name string }
+	if err := json.Unmarshal([]byte(lines[0]), &span); err != nil || span.Name != "op" {
+		t.Errorf("compact line is not the exported span (name %q, err %v): %s", span.Name, err, lines[0])
+	}
+
+	if out := consoleOutput(t, false); strings.Count(strings.TrimSpace(out), "\n") == 0 {
+		t.Errorf("default output is not indented:\n%s", out)
+	}
+}
+
+func TestSetConsoleExporter(t *testing.T) {
+	var buf bytes.Buffer
+	prevWriter := consoleWriter
+	consoleWriter = &buf
+	t.Cleanup(func() { consoleWriter = prevWriter })
+
+	cfg := testProviderConfig()
+	cfg.EnableConsoleExporter = true
+	cfg.ConsoleExporterCompact = true
+	cfg.EnableMetrics = false
+	p := newTestProvider(t, cfg)
+
+	// exportSpan 导出一个 span 并返回控制台新增的输出行数
+	exportSpan := func(name string) int {
+		before := strings.Count(buf.String(), "\n")
+		_, span := p.Tracer("test").Start(context.Background(), name)
+		span.End()
+		if err := p.traceProvider.provider.ForceFlush(context.Background()); err != nil {
+			t.Fatalf("ForceFlush: %v", err)
+		}
+		return strings.Count(buf.String(), "\n") - before
+	}
+
+	if n := exportSpan("enabled"); n != 1 {
+		t.Fatalf("console received %d spans while enabled, want 1", n)
+	}
+	p.SetConsoleExporter(false)
+	if n := exportSpan("disabled"); n != 0 {
+		t.Errorf("console received %d spans while disabled, want 0", n)
+	}
+	p.SetConsoleExporter(true)
+	if n := exportSpan("reenabled"); n != 1 {
+		t.Errorf("console received %d spans after re-enabling, want 1", n)
+	}
+	if strings.Contains(buf.String(), `"disabled"`) {
+		t.Error("span exported while disabled reached the console")
+	}
+}
+
+// slowExporter 每批导出耗时 delay，统计导出的 span 数
+type slowExporter struct {
+	delay    time.Duration
+	exported atomic.Int64
+}
+
+func (e *slowExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	time.Sleep(e.delay)
+	e.exported.Add(int64(len(spans)))
+	return nil
+}
+
+func (e *slowExporter) Shutdown(context.Context) error { return nil }
+
+// exportThroughTinyQueue 以配置的批处理器选项与容量为 1 的队列快速结束 n 个 span，返回导出的 span 数
+func exportThroughTinyQueue(cfg Config, n int) int64 {
+	exporter := &slowExporter{delay: 2 * time.Millisecond}
+	opts := append(batchSpanProcessorOptions(cfg), sdktrace.WithMaxQueueSize(1))
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter, opts...))
+	tracer := tp.Tracer("test")
+	for range n {
+		_, span := tracer.Start(context.Background(), "op")
+		span.End()
+	}
+	_ = tp.Shutdown(context.Background())
+	return exporter.exported.Load()
+}
+
+func TestBlockOnQueueFull(t *testing.T) {
+	cfg := testProviderConfig()
+	cfg.MaxExportBatchSize = 1
+
+	cfg.BlockOnQueueFull = true
+	if got := exportThroughTinyQueue(cfg, 50); got != 50 {
+		t.Errorf("blocking processor exported %d of 50 spans, want all", got)
+	}
+
+	cfg.BlockOnQueueFull = false
+	if got := exportThroughTinyQueue(cfg, 50); got >= 50 {
+		t.Errorf("non-blocking processor exported %d of 50 spans, want some dropped", got)
+	}
+}
@@ -0,0 +1,36 @@
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// crockfordAlphabet ULID 使用的 Crockford base32 字母表
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newDispatchID 生成一个 ULID（48 位毫秒时间戳 + 80 位随机数），按时间排序，用于关联同一次分发产生的 span
+func newDispatchID() string {
+	var id [16]byte
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(time.Now().UnixMilli()))
+	copy(id[:6], ts[2:])
+	_, _ = rand.Read(id[6:])
+
+	// 128 位按 5 位一组编码为 26 个字符，最高位补两个 0
+	bit := func(k int) byte {
+		if k < 0 {
+			return 0
+		}
+		return (id[k/8] >> (7 - uint(k%8))) & 1
+	}
+	out := make([]byte, 26)
+	for i := range out {
+		var v byte
+		for j := 0; j < 5; j++ {
+			v = v<<1 | bit(i*5+j-2)
+		}
+		out[i] = crockfordAlphabet[v]
+	}
+	return string(out)
+}
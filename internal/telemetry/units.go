@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+// unitAliases 常见的非 UCUM 单位写法到 UCUM 单位的映射
+var unitAliases = map[string]string{
+	"millisecond":  "ms",
+	"milliseconds": "ms",
+	"msec":         "ms",
+	"microsecond":  "us",
+	"microseconds": "us",
+	"nanosecond":   "ns",
+	"nanoseconds":  "ns",
+	"second":       "s",
+	"seconds":      "s",
+	"sec":          "s",
+	"minute":       "min",
+	"minutes":      "min",
+	"hour":         "h",
+	"hours":        "h",
+	"byte":         "By",
+	"bytes":        "By",
+	"B":            "By",
+	"KB":           "kBy",
+	"MB":           "MBy",
+	"GB":           "GBy",
+	"percent":      "%",
+}
+
+// ucumAtoms 允许加前缀的 UCUM 基本单位
+var ucumAtoms = map[string]bool{
+	"s":   true,
+	"By":  true,
+	"bit": true,
+	"Hz":  true,
+	"m":   true,
+	"g":   true,
+	"W":   true,
+	"J":   true,
+	"V":   true,
+	"A":   true,
+}
+
+// ucumStandalone 不加前缀使用的 UCUM 单位
+var ucumStandalone = map[string]bool{
+	"1":   true,
+	"%":   true,
+	"min": true,
+	"h":   true,
+	"d":   true,
+	"Cel": true,
+}
+
+// ucumPrefixes UCUM 十进制与二进制前缀
+var ucumPrefixes = []string{"Ki", "Mi", "Gi", "Ti", "k", "M", "G", "T", "m", "u", "n", "da", "c", "d"}
+
+// NormalizeUnit 将常见的错误写法（如 milliseconds）规范化为 UCUM 单位，并报告结果是否符合 UCUM 约定
+func NormalizeUnit(unit string) (string, bool) {
+	if alias, ok := unitAliases[unit]; ok {
+		unit = alias
+	}
+	return unit, isUCUMUnit(unit)
+}
+
+// isUCUMUnit 检查单位是否由 UCUM 单位、注释（如 {request}）与 / 、. 组合而成
+func isUCUMUnit(unit string) bool {
+	if unit == "" {
+		return true
+	}
+	for _, term := range strings.FieldsFunc(unit, func(r rune) bool { return r == '/' || r == '.' }) {
+		if !isUCUMTerm(term) {
+			return false
+		}
+	}
+	return !strings.HasPrefix(unit, "/") && !strings.HasSuffix(unit, "/")
+}
+
+// isUCUMTerm 检查单个单位项
+func isUCUMTerm(term string) bool {
+	if strings.HasPrefix(term, "{") && strings.HasSuffix(term, "}") && len(term) > 2 {
+		return !strings.ContainsAny(term[1:len(term)-1], "{}")
+	}
+	if ucumStandalone[term] || ucumAtoms[term] {
+		return true
+	}
+	for _, prefix := range ucumPrefixes {
+		if strings.HasPrefix(term, prefix) && ucumAtoms[strings.TrimPrefix(term, prefix)] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUnit 规范化单位，不符合 UCUM 约定时输出警告
+func checkUnit(name, unit string) string {
+	normalized, ok := NormalizeUnit(unit)
+	if normalized != unit {
+		zap.L().Warn("Normalized metric unit",
+			zap.String("instrument", name),
+			zap.String("unit", unit),
+			zap.String("normalized", normalized),
+		)
+	}
+	if !ok {
+		zap.L().Warn("Metric unit does not conform to UCUM",
+			zap.String("instrument", name),
+			zap.String("unit", normalized),
+		)
+	}
+	return normalized
+}
+
+// NewHistogram 创建 Float64 直方图，创建前校验并规范化单位
+func NewHistogram(meter metric.Meter, name, unit, description string) (metric.Float64Histogram, error) {
+	return meter.Float64Histogram(name,
+		metric.WithUnit(checkUnit(name, unit)),
+		metric.WithDescription(description),
+	)
+}
+
+// NewCounter 创建 Int64 计数器，创建前校验并规范化单位
+func NewCounter(meter metric.Meter, name, unit, description string) (metric.Int64Counter, error) {
+	return meter.Int64Counter(name,
+		metric.WithUnit(checkUnit(name, unit)),
+		metric.WithDescription(description),
+	)
+}
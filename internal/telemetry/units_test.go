@@ -0,0 +1,108 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNormalizeUnit(t *testing.T) {
+	tests := []struct {
+		unit  string
+		want  string
+		valid bool
+	}{
+		{unit: "", want: "", valid: true},
+		{unit: "ms", want: "ms", valid: true},
+		{unit: "milliseconds", want: "ms", valid: true},
+		{unit: "seconds", want: "s", valid: true},
+		{unit: "bytes", want: "By", valid: true},
+		{unit: "MB", want: "MBy", valid: true},
+		{unit: "KiBy", want: "KiBy", valid: true},
+		{unit: "percent", want: "%", valid: true},
+		{unit: "{request}", want: "{request}", valid: true},
+		{unit: "By/s", want: "By/s", valid: true},
+		{unit: "{packet}.s", want: "{packet}.s", valid: true},
+		{unit: "requests", want: "requests", valid: false},
+		{unit: "/s", want: "/s", valid: false},
+		{unit: "{}", want: "{}", valid: false},
+		{unit: "xs", want: "xs", valid: false},
+	}
+	for _, tt := range tests {
+		got, valid := NormalizeUnit(tt.unit)
+		if got != tt.want || valid != tt.valid {
+			t.Errorf("NormalizeUnit(%q) = %q, %v, want %q, %v", tt.unit, got, valid, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestNewInstrumentsNormalizeUnits(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	t.Cleanup(zap.ReplaceGlobals(zap.New(core)))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	t.Cleanup(func() { _ = mp.Shutdown(context.Background()) })
+	meter := mp.Meter("units-test")
+
+	tests := []struct {
+		name     string
+		unit     string
+		want     string
+		warnings int
+		record   func(name, unit string) error
+	}{
+		{name: "latency", unit: "milliseconds", want: "ms", warnings: 1, record: func(name, unit string) error {
+			h, err := NewHistogram(meter, name, unit, "request latency")
+			if err != nil {
+				return err
+			}
+			h.Record(context.Background(), 1)
+			return nil
+		}},
+		{name: "payload", unit: "By", want: "By", warnings: 0, record: func(name, unit string) error {
+			h, err := NewHistogram(meter, name, unit, "payload size")
+			if err != nil {
+				return err
+			}
+			h.Record(context.Background(), 1)
+			return nil
+		}},
+		{name: "requests", unit: "{request}", want: "{request}", warnings: 0, record: func(name, unit string) error {
+			c, err := NewCounter(meter, name, unit, "handled requests")
+			if err != nil {
+				return err
+			}
+			c.Add(context.Background(), 1)
+			return nil
+		}},
+		{name: "widgets", unit: "widgets", want: "widgets", warnings: 1, record: func(name, unit string) error {
+			c, err := NewCounter(meter, name, unit, "produced widgets")
+			if err != nil {
+				return err
+			}
+			c.Add(context.Background(), 1)
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := logs.Len()
+			if err := tt.record(tt.name, tt.unit); err != nil {
+				t.Fatal(err)
+			}
+			_, m, ok := collectMetric(t, reader, tt.name)
+			if !ok {
+				t.Fatalf("%s not collected", tt.name)
+			}
+			if m.Unit != tt.want {
+				t.Errorf("unit = %q, want %q", m.Unit, tt.want)
+			}
+			if got := logs.Len() - before; got != tt.warnings {
+				t.Errorf("logged %d warnings, want %d", got, tt.warnings)
+			}
+		})
+	}
+}